@@ -10,10 +10,14 @@ import (
 
 	"github.com/risingwavelabs/eris"
 
+	"freyr/internal/arbitrage"
 	"freyr/internal/config"
 	"freyr/internal/database"
 	"freyr/internal/exchanges/binance"
+	"freyr/internal/exchanges/bybit"
 	"freyr/internal/exchanges/coinbase"
+	"freyr/internal/exchanges/kraken"
+	"freyr/internal/exchanges/okx"
 	"freyr/internal/metrics"
 	"freyr/internal/services"
 )
@@ -59,6 +63,11 @@ func run(ctx context.Context) error {
 		&metrics.Server{},
 		&binance.Binance{},
 		&coinbase.Coinbase{},
+		&coinbase.OrderBooks{},
+		&kraken.Kraken{},
+		&okx.Okx{},
+		&bybit.Bybit{},
+		&arbitrage.Monitor{},
 	})
 	if err != nil {
 		return eris.Wrap(err, "error while running services")
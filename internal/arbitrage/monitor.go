@@ -0,0 +1,200 @@
+package arbitrage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+	"freyr/internal/metrics"
+	"freyr/internal/order"
+)
+
+// Buying a pair on one exchange and selling it on another.
+type route struct {
+	pair string
+	buy  string
+	sell string
+}
+
+// A period during which a route was profitable.
+type opportunity struct {
+	start time.Time
+
+	// The largest values observed during the period.
+	maxSize   float64
+	maxEdge   float64
+	maxProfit float64
+}
+
+// Continuously compares the live order books of all venues and records
+// periods during which buying on one and selling on another was profitable
+// after taker fees.
+type Monitor struct {
+	insts []exchanges.Instrument
+
+	// Spreads of the latest comparison.
+	spreads map[route]Spread
+
+	// Opportunities which have not ended yet.
+	open map[route]*opportunity
+}
+
+func (m *Monitor) Name() string { return "Arbitrage Monitor" }
+
+func (m *Monitor) Init(_ context.Context) error {
+	m.insts = make([]exchanges.Instrument, 0, len(config.C.Arbitrage.Pairs))
+	for _, pair := range config.C.Arbitrage.Pairs {
+		inst, err := exchanges.ParseInstrument(pair)
+		if err != nil {
+			return eris.Wrap(err, "invalid arbitrage configuration")
+		}
+		m.insts = append(m.insts, inst)
+	}
+
+	m.spreads = map[route]Spread{}
+	m.open = map[route]*opportunity{}
+
+	return nil
+}
+
+func (m *Monitor) Run(ctx context.Context) error {
+	if len(m.insts) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	ticker := time.NewTicker(config.C.Arbitrage.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Record the opportunities which are still open.
+			now := time.Now()
+			for r := range m.open {
+				m.end(context.WithoutCancel(ctx), r, now)
+			}
+			return nil
+
+		case now := <-ticker.C:
+			spreads := map[route]Spread{}
+			for _, inst := range m.insts {
+				compare(inst, spreads, now)
+			}
+			m.observe(ctx, spreads, now)
+		}
+	}
+}
+
+// Computes the spreads between the books of all pairs of exchanges of the
+// given instrument. If an exchange maintains several books of the instrument
+// (in different quote currencies), the best spread is used. Stale books are
+// skipped, which ends the opportunities of their routes.
+func compare(inst exchanges.Instrument, spreads map[route]Spread, now time.Time) {
+	sources := exchanges.BookSources(inst, true)
+	sources = slices.DeleteFunc(sources, func(source order.Source) bool {
+		return isStale(source.View, now)
+	})
+
+	// Apply rates and fees to the prices.
+	views := make([]order.View, len(sources))
+	for i, source := range sources {
+		views[i] = order.Consolidate(source).View
+	}
+
+	for i, buy := range sources {
+		for j, sell := range sources {
+			if buy.Exchange == sell.Exchange {
+				continue
+			}
+
+			spread, ok := computeSpread(views[i], views[j])
+			if !ok {
+				continue
+			}
+
+			r := route{pair: inst.String(), buy: buy.Exchange, sell: sell.Exchange}
+			if prev, seen := spreads[r]; !seen || spread.TopBps > prev.TopBps {
+				spreads[r] = spread
+			}
+		}
+	}
+}
+
+// Returns whether the given book was not updated within the configured
+// maximum age. Books without a timed update (e.g. right after a snapshot) are
+// considered stale as well.
+func isStale(view order.View, now time.Time) bool {
+	return view.Timestamp() < 0 || now.Sub(time.UnixMilli(view.Timestamp())) > config.C.Arbitrage.MaxBookAge
+}
+
+// Updates the metrics and the open opportunities with the latest spreads.
+// Routes without spread (e.g. since a book is being rebuilt) end their
+// opportunities.
+func (m *Monitor) observe(ctx context.Context, spreads map[route]Spread, now time.Time) {
+	for r, spread := range spreads {
+		metrics.ArbitrageSpread.WithLabelValues(r.pair, r.buy, r.sell).Set(spread.TopBps)
+		metrics.ArbitrageSize.WithLabelValues(r.pair, r.buy, r.sell).Set(spread.Size)
+
+		if spread.Size == 0 || spread.EdgeBps() < config.C.Arbitrage.MinEdgeBps {
+			m.end(ctx, r, now)
+			continue
+		}
+
+		opp, ok := m.open[r]
+		if !ok {
+			opp = &opportunity{start: now}
+			m.open[r] = opp
+		}
+		opp.maxSize = max(opp.maxSize, spread.Size)
+		opp.maxEdge = max(opp.maxEdge, spread.EdgeBps())
+		opp.maxProfit = max(opp.maxProfit, spread.Profit())
+	}
+
+	for r := range m.spreads {
+		if _, ok := spreads[r]; !ok {
+			metrics.ArbitrageSpread.DeleteLabelValues(r.pair, r.buy, r.sell)
+			metrics.ArbitrageSize.DeleteLabelValues(r.pair, r.buy, r.sell)
+			m.end(ctx, r, now)
+		}
+	}
+
+	m.spreads = spreads
+}
+
+// Records the open opportunity of the given route (if any). Failures are
+// reported and the opportunity is dropped.
+func (m *Monitor) end(ctx context.Context, r route, now time.Time) {
+	opp, ok := m.open[r]
+	if !ok {
+		return
+	}
+	delete(m.open, r)
+
+	metrics.ArbitrageOpportunities.WithLabelValues(r.pair, r.buy, r.sell).Inc()
+
+	err := database.InsertArbitrageOpportunity(ctx, querier.InsertArbitrageOpportunityParams{
+		Pair:         r.pair,
+		BuyExchange:  r.buy,
+		SellExchange: r.sell,
+		StartedAt:    opp.start,
+		EndedAt:      now,
+		MaxSize:      opp.maxSize,
+		MaxEdgeBps:   opp.maxEdge,
+		MaxProfit:    opp.maxProfit,
+	})
+	if err != nil {
+		fmt.Printf("Failed to store arbitrage opportunity: %s\n", eris.ToString(err, true))
+	}
+}
+
+func (m *Monitor) Stop() error {
+	return nil
+}
@@ -0,0 +1,28 @@
+package arbitrage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/config"
+	"freyr/internal/order"
+)
+
+// Ensures that books are only compared while they receive updates.
+func TestIsStale(t *testing.T) {
+	t.Parallel()
+
+	book := order.NewBook(order.Precision{Price: 2, Amount: 2}, 1, time.Minute)
+	now := time.UnixMilli(1_700_000_000_000)
+
+	// The time of a snapshot is unknown.
+	book.Update([]order.Level{{Price: 100_00, Amount: 1_00}}, nil, 0, -1)
+	require.True(t, isStale(book.Snapshot(), now))
+
+	book.Update(nil, []order.Level{{Price: 99_00, Amount: 1_00}}, 0, now.UnixMilli())
+	require.False(t, isStale(book.Snapshot(), now))
+	require.False(t, isStale(book.Snapshot(), now.Add(config.C.Arbitrage.MaxBookAge)))
+	require.True(t, isStale(book.Snapshot(), now.Add(config.C.Arbitrage.MaxBookAge+time.Millisecond)))
+}
@@ -0,0 +1,82 @@
+package arbitrage
+
+import (
+	"iter"
+
+	"freyr/internal/order"
+)
+
+// The outcome of buying on one book and simultaneously selling on another.
+type Spread struct {
+	// The difference between the best bid and the best ask relative to the
+	// ask (in bps). Positive values indicate an opportunity.
+	TopBps float64
+
+	// The amount (in the base currency) which can be bought and sold at a
+	// profit, along with the quote amounts paid and received for it.
+	Size     float64
+	Cost     float64
+	Proceeds float64
+}
+
+func (s Spread) Profit() float64 {
+	return s.Proceeds - s.Cost
+}
+
+// Returns the profit relative to the cost (in bps), or 0 if there is no
+// profitable amount.
+func (s Spread) EdgeBps() float64 {
+	if s.Cost == 0 {
+		return 0
+	}
+	return s.Profit() / s.Cost * 10_000
+}
+
+// Walks the asks of `buy` and the bids of `sell` while the ask is below the
+// bid. Both books need the same quote currency; fees are expected to be
+// included in their prices. The second return value is false if either side
+// is empty.
+func computeSpread(buy, sell order.View) (Spread, bool) {
+	nextAsk, stopAsks := iter.Pull(buy.Asks())
+	defer stopAsks()
+	nextBid, stopBids := iter.Pull(sell.Bids())
+	defer stopBids()
+
+	ask, okAsk := nextAsk()
+	bid, okBid := nextBid()
+	if !okAsk || !okBid {
+		return Spread{}, false
+	}
+
+	askPrice, askLeft := buy.Precision().PriceFloat(ask.Price), buy.Precision().AmountFloat(ask.Amount)
+	bidPrice, bidLeft := sell.Precision().PriceFloat(bid.Price), sell.Precision().AmountFloat(bid.Amount)
+
+	spread := Spread{TopBps: (bidPrice - askPrice) / askPrice * 10_000}
+
+	for askPrice < bidPrice {
+		take := min(askLeft, bidLeft)
+		spread.Size += take
+		spread.Cost += take * askPrice
+		spread.Proceeds += take * bidPrice
+
+		askLeft -= take
+		bidLeft -= take
+
+		if askLeft <= 0 {
+			ask, okAsk = nextAsk()
+			if !okAsk {
+				break
+			}
+			askPrice, askLeft = buy.Precision().PriceFloat(ask.Price), buy.Precision().AmountFloat(ask.Amount)
+		}
+		if bidLeft <= 0 {
+			bid, okBid = nextBid()
+			if !okBid {
+				break
+			}
+			bidPrice, bidLeft = sell.Precision().PriceFloat(bid.Price), sell.Precision().AmountFloat(bid.Amount)
+		}
+	}
+
+	return spread, true
+}
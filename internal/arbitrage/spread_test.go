@@ -0,0 +1,47 @@
+package arbitrage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/order"
+)
+
+// Only the amount which is still profitable after fees may be counted.
+func TestComputeSpread(t *testing.T) {
+	t.Parallel()
+
+	prec := order.Precision{Price: 2, Amount: 2}
+	asks := order.NewBook(prec, 1, time.Minute)
+	asks.Update([]order.Level{{Price: 10000, Amount: 100}, {Price: 10100, Amount: 200}, {Price: 10400, Amount: 500}}, nil, 0, -1)
+	bids := order.NewBook(prec, 1, time.Minute)
+	bids.Update(nil, []order.Level{{Price: 10500, Amount: 150}, {Price: 10400, Amount: 300}}, 0, -1)
+
+	buy := order.Consolidate(order.Source{View: asks.Snapshot(), Rate: 1, Fee: 0.01}).View
+	sell := order.Consolidate(order.Source{View: bids.Snapshot(), Rate: 1, Fee: 0.01}).View
+
+	// Asks of 101, 102.01, and 105.04 against bids of 103.95 and 102.96.
+	spread, ok := computeSpread(buy, sell)
+	require.True(t, ok)
+	require.InDelta(t, (103.95-101)/101*10_000, spread.TopBps, 1e-9)
+	require.InDelta(t, 3, spread.Size, 1e-9)
+	require.InDelta(t, 101+2*102.01, spread.Cost, 1e-9)
+	require.InDelta(t, 1.5*103.95+1.5*102.96, spread.Proceeds, 1e-9)
+	require.Positive(t, spread.EdgeBps())
+
+	// The reverse direction is not profitable.
+	spread, ok = computeSpread(sell, buy)
+	require.False(t, ok)
+	require.Zero(t, spread.Size)
+
+	// Without any overlap, only the top of the books is compared.
+	expensive := order.NewBook(prec, 1, time.Minute)
+	expensive.Update([]order.Level{{Price: 11000, Amount: 100}}, nil, 0, -1)
+	spread, ok = computeSpread(order.Consolidate(order.Source{View: expensive.Snapshot(), Rate: 1}).View, sell)
+	require.True(t, ok)
+	require.Negative(t, spread.TopBps)
+	require.Zero(t, spread.Size)
+	require.Zero(t, spread.EdgeBps())
+}
@@ -3,9 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/risingwavelabs/eris"
 	"gopkg.in/yaml.v3"
+
+	"freyr/internal/order"
 )
 
 // The program's configuration with default values. The latter ensures the
@@ -22,6 +25,106 @@ var C = Config{
 		Password: "freyr",
 		Name:     "freyr",
 	},
+
+	OrderBook: obConfig{
+		CacheWindow: 10 * time.Minute,
+	},
+
+	Binance: binanceConfig{
+		Symbols: []BinanceSymbol{
+			{
+				Symbol:      "BTCUSDT",
+				Pair:        "btc-usdt",
+				Precision:   order.Precision{Price: 2, Amount: 8},
+				Granularity: "1.00",
+				UpdateSpeed: 100 * time.Millisecond,
+				Trades:      "trade",
+				Candles:     true,
+			},
+		},
+		Record: recordConfig{
+			RotateInterval: time.Hour,
+		},
+		Replay: replayConfig{
+			Speed: 1.0,
+		},
+	},
+
+	Coinbase: coinbaseConfig{
+		Products: []CoinbaseProduct{
+			{Product: "btc-usd", Granularities: []int64{60}},
+			{Product: "sol-usd", Granularities: []int64{60}},
+			{Product: "sol-btc", Granularities: []int64{60}},
+			{Product: "eth-usd", Granularities: []int64{60}},
+		},
+		Discover: coinbaseDiscovery{
+			Statuses:      []string{"online"},
+			Granularities: []int64{60},
+		},
+		CatalogueInterval: time.Hour,
+		Books: []CoinbaseBook{
+			{
+				Product:     "BTC-USD",
+				Pair:        "btc-usd",
+				Precision:   order.Precision{Price: 2, Amount: 8},
+				Granularity: "0.01",
+			},
+		},
+	},
+
+	Kraken: krakenConfig{
+		Books: []KrakenBook{
+			{
+				Symbol:      "BTC/USD",
+				Pair:        "btc-usd",
+				Precision:   order.Precision{Price: 1, Amount: 8},
+				Granularity: "0.1",
+			},
+		},
+		Depth: 10,
+	},
+
+	Okx: okxConfig{
+		Books: []OkxBook{
+			{
+				InstID:      "BTC-USDT",
+				Pair:        "btc-usdt",
+				Precision:   order.Precision{Price: 1, Amount: 8},
+				Granularity: "0.1",
+			},
+		},
+	},
+
+	Bybit: bybitConfig{
+		Books: []BybitBook{
+			{
+				Symbol:      "BTCUSDT",
+				Pair:        "btc-usdt",
+				Precision:   order.Precision{Price: 2, Amount: 6},
+				Granularity: "0.01",
+			},
+		},
+		Depth: 50,
+	},
+
+	Consolidation: consolidationConfig{
+		TakerFees: map[string]float64{
+			"binance":  0.001,
+			"coinbase": 0.006,
+			"kraken":   0.004,
+			"okx":      0.001,
+			"bybit":    0.001,
+		},
+		QuoteRates: map[string]map[string]float64{
+			"usdt": {"usd": 1.0},
+		},
+	},
+
+	Arbitrage: arbitrageConfig{
+		Pairs:      []string{"btc-usd"},
+		Interval:   time.Second,
+		MaxBookAge: 10 * time.Second,
+	},
 }
 
 type dbConfig struct {
@@ -32,6 +135,226 @@ type dbConfig struct {
 	Name     string `yaml:"name"`
 }
 
+type obConfig struct {
+	// How long past states of order books are retained.
+	CacheWindow time.Duration `yaml:"cacheWindow"`
+
+	// Directory in which order books are stored on shutdown and from which
+	// they are restored on start. Disabled if empty.
+	SnapshotDir string `yaml:"snapshotDir"`
+}
+
+type recordConfig struct {
+	// Directory into which raw data is recorded. Disabled if empty.
+	Dir string `yaml:"dir"`
+
+	// How long a single file is written before starting a new one.
+	RotateInterval time.Duration `yaml:"rotateInterval"`
+}
+
+type replayConfig struct {
+	// Glob pattern of the recorded files to replay. Disabled if empty.
+	Files string `yaml:"files"`
+
+	// Replay speed relative to the recording. A speed of 0 replays as fast as
+	// possible.
+	Speed float64 `yaml:"speed"`
+}
+
+type BinanceSymbol struct {
+	// The symbol as used by Binance, e.g. `BTCUSDT`. Derived from the pair if
+	// empty.
+	Symbol string `yaml:"symbol"`
+
+	// The canonical name of the pair used in the database and in metrics,
+	// e.g. `btc-usdt`.
+	Pair string `yaml:"pair"`
+
+	// Decimals of prices and amounts as send by Binance.
+	Precision order.Precision `yaml:"precision"`
+
+	// Granularity of the order book as decimal, e.g. `1.00`.
+	Granularity string `yaml:"granularity"`
+
+	// Update speed of the depth stream: either 100ms or 1s.
+	UpdateSpeed time.Duration `yaml:"updateSpeed"`
+
+	// Trade stream stored in the database: `trade`, `aggTrade`, or empty to
+	// not collect trades.
+	Trades string `yaml:"trades"`
+
+	// Whether 1m-candles are stored in the database.
+	Candles bool `yaml:"candles"`
+}
+
+type binanceConfig struct {
+	// Symbols for which order books are maintained.
+	Symbols []BinanceSymbol `yaml:"symbols"`
+	// Records the depth stream and snapshots received from Binance.
+	Record recordConfig `yaml:"record"`
+
+	// Replays recorded data instead of connecting to Binance.
+	Replay replayConfig `yaml:"replay"`
+}
+
+type CoinbaseProduct struct {
+	// The product as used by Coinbase, e.g. `btc-usd`.
+	Product string `yaml:"product"`
+
+	// Widths of the collected candles in seconds. Coinbase supports 60, 300,
+	// 900, 3600, 21600, and 86400.
+	Granularities []int64 `yaml:"granularities"`
+
+	// The oldest candle collected, e.g. `2024-01-01`. Defaults to 2020-01-01.
+	Start time.Time `yaml:"start"`
+}
+
+type CoinbaseBook struct {
+	// The product as used by Coinbase, e.g. `BTC-USD`. Derived from the pair
+	// if empty.
+	Product string `yaml:"product"`
+
+	// The canonical name of the pair used in metrics, e.g. `btc-usd`.
+	// Derived from the product if empty.
+	Pair string `yaml:"pair"`
+
+	// Decimals of prices and amounts as send by Coinbase.
+	Precision order.Precision `yaml:"precision"`
+
+	// Granularity of the order book as decimal, e.g. `0.01`.
+	Granularity string `yaml:"granularity"`
+}
+
+type coinbaseDiscovery struct {
+	// Collects candles of all listed products matching the filters below.
+	Enabled bool `yaml:"enabled"`
+
+	// Only products quoted in one of these currencies, e.g. `USD`. All if
+	// empty.
+	QuoteCurrencies []string `yaml:"quoteCurrencies"`
+
+	// Only products with one of these statuses, e.g. `online`. All (except
+	// delisted ones) if empty.
+	Statuses []string `yaml:"statuses"`
+
+	// Widths of the collected candles in seconds.
+	Granularities []int64 `yaml:"granularities"`
+
+	// The oldest candle collected. Defaults to 2020-01-01.
+	Start time.Time `yaml:"start"`
+}
+
+type coinbaseConfig struct {
+	// Products for which candles are collected.
+	Products []CoinbaseProduct `yaml:"products"`
+
+	// Collects candles of products found in Coinbase's catalogue.
+	Discover coinbaseDiscovery `yaml:"discover"`
+
+	// How often the catalogue of products is updated.
+	CatalogueInterval time.Duration `yaml:"catalogueInterval"`
+
+	// Products for which order books are maintained.
+	Books []CoinbaseBook `yaml:"books"`
+}
+
+type KrakenBook struct {
+	// The symbol as used by Kraken, e.g. `BTC/USD`. Derived from the pair if
+	// empty.
+	Symbol string `yaml:"symbol"`
+
+	// The canonical name of the pair used in metrics, e.g. `btc-usd`.
+	// Derived from the symbol if empty.
+	Pair string `yaml:"pair"`
+
+	// Decimals of prices and amounts. They must match Kraken's price and
+	// quantity precision of the pair since checksums are computed from them.
+	Precision order.Precision `yaml:"precision"`
+
+	// Granularity of the order book as decimal, e.g. `0.1`.
+	Granularity string `yaml:"granularity"`
+}
+
+type krakenConfig struct {
+	// Pairs for which order books are maintained.
+	Books []KrakenBook `yaml:"books"`
+
+	// Number of levels per side received from Kraken: 10, 25, 100, 500, or
+	// 1000.
+	Depth int `yaml:"depth"`
+}
+
+type OkxBook struct {
+	// The instrument ID as used by OKX, e.g. `BTC-USDT`. Derived from the
+	// pair if empty.
+	InstID string `yaml:"instId"`
+
+	// The canonical name of the pair used in metrics, e.g. `btc-usdt`.
+	// Derived from the instrument ID if empty.
+	Pair string `yaml:"pair"`
+
+	// Decimals of prices and amounts as send by OKX.
+	Precision order.Precision `yaml:"precision"`
+
+	// Granularity of the order book as decimal, e.g. `0.1`.
+	Granularity string `yaml:"granularity"`
+}
+
+type okxConfig struct {
+	// Instruments for which order books are maintained.
+	Books []OkxBook `yaml:"books"`
+}
+
+type BybitBook struct {
+	// The symbol as used by Bybit, e.g. `BTCUSDT`. Derived from the pair if
+	// empty.
+	Symbol string `yaml:"symbol"`
+
+	// The canonical name of the pair used in metrics, e.g. `btc-usdt`.
+	Pair string `yaml:"pair"`
+
+	// Decimals of prices and amounts as send by Bybit.
+	Precision order.Precision `yaml:"precision"`
+
+	// Granularity of the order book as decimal, e.g. `0.01`.
+	Granularity string `yaml:"granularity"`
+}
+
+type bybitConfig struct {
+	// Spot symbols for which order books are maintained.
+	Books []BybitBook `yaml:"books"`
+
+	// Number of levels per side received from Bybit: 1, 50, 200, or 1000.
+	Depth int `yaml:"depth"`
+}
+
+type consolidationConfig struct {
+	// Taker fees per venue as fraction, e.g. `binance: 0.001` for 10 bps.
+	TakerFees map[string]float64 `yaml:"takerFees"`
+
+	// Rates between quote currencies which are treated as equivalent, e.g.
+	// `usdt: {usd: 1.0}` if one USDT is worth one USD. Books quoted in either
+	// currency are merged into consolidated books of the other one.
+	QuoteRates map[string]map[string]float64 `yaml:"quoteRates"`
+}
+
+type arbitrageConfig struct {
+	// Canonical pairs compared across venues, e.g. `btc-usd`. Books quoted in
+	// equivalent currencies are included (see `consolidation.quoteRates`).
+	Pairs []string `yaml:"pairs"`
+
+	// How often the books are compared.
+	Interval time.Duration `yaml:"interval"`
+
+	// Books without an update within this duration (e.g. since their feed
+	// stalled) are not compared.
+	MaxBookAge time.Duration `yaml:"maxBookAge"`
+
+	// The minimum edge (in bps, net of taker fees) of an opportunity to be
+	// recorded.
+	MinEdgeBps float64 `yaml:"minEdgeBps"`
+}
+
 type Config struct {
 	// Define config structure here.
 	// Do not forget the `yaml` or `json` tags.
@@ -40,6 +363,24 @@ type Config struct {
 	MetricsPort uint16 `yaml:"metricsPort"`
 
 	Database dbConfig `yaml:"database"`
+
+	OrderBook obConfig `yaml:"orderBook"`
+
+	Binance binanceConfig `yaml:"binance"`
+
+	Coinbase coinbaseConfig `yaml:"coinbase"`
+
+	Kraken krakenConfig `yaml:"kraken"`
+
+	Okx okxConfig `yaml:"okx"`
+
+	Bybit bybitConfig `yaml:"bybit"`
+
+	// Merging order books of several venues.
+	Consolidation consolidationConfig `yaml:"consolidation"`
+
+	// Monitoring price differences between venues.
+	Arbitrage arbitrageConfig `yaml:"arbitrage"`
 }
 
 func (c *Config) Load(configPath string) error {
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database/querier"
+)
+
+// Returns the arbitrage opportunities of a pair which started between
+// `start` (inclusive) and `end` (exclusive).
+func GetArbitrageOpportunities(ctx context.Context, pair string, start, end time.Time) ([]*querier.ArbitrageOpportunity, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	res, err := model.GetArbitrageOpportunities(ctx, querier.GetArbitrageOpportunitiesParams{
+		Pair:      pair,
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query arbitrage opportunities")
+	}
+
+	return res, nil
+}
+
+func InsertArbitrageOpportunity(ctx context.Context, opportunity querier.InsertArbitrageOpportunityParams) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	err := model.InsertArbitrageOpportunity(ctx, opportunity)
+	if err != nil {
+		return eris.Wrapf(err, "failed to insert arbitrage opportunity")
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database/querier"
+)
+
+// Returns up to `maxGaps` ranges of missing candles between stored ones,
+// oldest first. Ranges recorded via `RecordCandleGap` are excluded.
+func FindCandleGaps(
+	ctx context.Context,
+	exchange, pair string,
+	intervalSeconds int32,
+	maxGaps int32,
+) ([]*querier.FindCandleGapsRow, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	res, err := model.FindCandleGaps(ctx, querier.FindCandleGapsParams{
+		Exchange:        exchange,
+		Pair:            pair,
+		IntervalSeconds: intervalSeconds,
+		MaxGaps:         maxGaps,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query candle gaps")
+	}
+
+	return res, nil
+}
+
+// Records that the exchange has no candles from `start` to `end` (both
+// inclusive), so that the range is not downloaded again.
+func RecordCandleGap(
+	ctx context.Context,
+	exchange, pair string,
+	intervalSeconds int32,
+	start, end time.Time,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	err := model.RecordCandleGap(ctx, querier.RecordCandleGapParams{
+		Exchange:        exchange,
+		Pair:            pair,
+		IntervalSeconds: intervalSeconds,
+		GapStart:        start,
+		GapEnd:          end,
+	})
+	if err != nil {
+		return eris.Wrapf(err, "failed to record candle gap")
+	}
+
+	return nil
+}
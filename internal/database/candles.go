@@ -10,14 +10,21 @@ import (
 	"freyr/internal/database/querier"
 )
 
-func GetCandles(ctx context.Context, pair string, start, end time.Time) ([]*querier.Candle, error) {
+func GetCandles(
+	ctx context.Context,
+	exchange, pair string,
+	intervalSeconds int32,
+	start, end time.Time,
+) ([]*querier.Candle, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 	defer cancel()
 
 	res, err := model.GetCandles(ctx, querier.GetCandlesParams{
-		Pair:      pair,
-		StartTime: start,
-		EndTime:   end,
+		Exchange:        exchange,
+		Pair:            pair,
+		IntervalSeconds: intervalSeconds,
+		StartTime:       start,
+		EndTime:         end,
 	})
 	if err != nil {
 		return nil, eris.Wrapf(err, "failed to query candles")
@@ -26,23 +33,76 @@ func GetCandles(ctx context.Context, pair string, start, end time.Time) ([]*quer
 	return res, nil
 }
 
-func InsertCandles(ctx context.Context, candles []querier.InsertCandlesParams) error {
-	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+// The outcome of storing candles.
+type CandleUpsert struct {
+	Inserted  int // New candles.
+	Updated   int // Candles which were already stored with different values.
+	Unchanged int // Candles which were already stored with the same values.
+}
+
+// Stores the given candles. Already stored candles are overwritten, i.e.,
+// storing the same candles again is no error.
+func UpsertCandles(ctx context.Context, candles []querier.StageCandlesParams) (res CandleUpsert, err error) {
+	if len(candles) == 0 {
+		return CandleUpsert{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, TransactionTimeout)
 	defer cancel()
 
-	_, err := model.InsertCandles(ctx, candles)
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return CandleUpsert{}, eris.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	txModel := model.WithTx(tx)
+
+	_, err = txModel.StageCandles(ctx, candles)
+	if err != nil {
+		return CandleUpsert{}, eris.Wrap(err, "failed to stage candles")
+	}
+
+	changed, err := txModel.MergeStagedCandles(ctx)
+	if err != nil {
+		return CandleUpsert{}, eris.Wrap(err, "failed to merge candles")
+	}
+
+	err = txModel.ClearStagedCandles(ctx)
 	if err != nil {
-		return eris.Wrapf(err, "failed to insert candles")
+		return CandleUpsert{}, eris.Wrap(err, "failed to clear staged candles")
 	}
 
-	return nil
+	err = tx.Commit(ctx)
+	if err != nil {
+		return CandleUpsert{}, eris.Wrap(err, "failed to commit candles")
+	}
+
+	for _, inserted := range changed {
+		if inserted {
+			res.Inserted++
+		} else {
+			res.Updated++
+		}
+	}
+	res.Unchanged = len(candles) - res.Inserted - res.Updated
+
+	return res, nil
 }
 
-func GetLatestCandle(ctx context.Context, pair string) (time.Time, error) {
+func GetLatestCandle(ctx context.Context, exchange, pair string, intervalSeconds int32) (time.Time, error) {
 	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
 	defer cancel()
 
-	ts, err := model.GetLatestCandle(ctx, pair)
+	ts, err := model.GetLatestCandle(ctx, querier.GetLatestCandleParams{
+		Exchange:        exchange,
+		Pair:            pair,
+		IntervalSeconds: intervalSeconds,
+	})
 	if err == pgx.ErrNoRows {
 		return time.Time{}, nil
 	} else if err != nil {
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database/querier"
+)
+
+// Returns the time of the first candle of a product, or the zero time if it
+// is not known yet.
+func GetListing(ctx context.Context, exchange, product string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	ts, err := model.GetListing(ctx, querier.GetListingParams{
+		Exchange: exchange,
+		Product:  product,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, nil
+	} else if err != nil {
+		return time.Time{}, eris.Wrapf(err, "failed to query listing")
+	}
+
+	return ts, nil
+}
+
+func SaveListing(ctx context.Context, exchange, product string, firstCandle time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	err := model.SaveListing(ctx, querier.SaveListingParams{
+		Exchange:    exchange,
+		Product:     product,
+		FirstCandle: firstCandle,
+	})
+	if err != nil {
+		return eris.Wrapf(err, "failed to save listing")
+	}
+
+	return nil
+}
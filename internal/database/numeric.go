@@ -0,0 +1,24 @@
+package database
+
+import (
+	"math/big"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/order"
+)
+
+// Parses a decimal number (as send by exchanges) into an exact numeric value.
+func ParseNumeric(s string) (pgtype.Numeric, error) {
+	value, decimals, err := order.ParseDecimal(s)
+	if err != nil {
+		return pgtype.Numeric{}, eris.Wrapf(err, "failed to parse numeric")
+	}
+
+	return pgtype.Numeric{
+		Int:   big.NewInt(value),
+		Exp:   -int32(decimals),
+		Valid: true,
+	}, nil
+}
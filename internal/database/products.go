@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database/querier"
+)
+
+func GetProducts(ctx context.Context, exchange string) ([]*querier.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	res, err := model.GetProducts(ctx, exchange)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query products")
+	}
+
+	return res, nil
+}
+
+// Stores the given products of an exchange. Stored products which are not
+// among them are marked as delisted. Returns the number of those.
+func UpdateProducts(
+	ctx context.Context,
+	exchange string,
+	products []querier.UpsertProductParams,
+) (delisted int64, err error) {
+	ctx, cancel := context.WithTimeout(ctx, TransactionTimeout)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	txModel := model.WithTx(tx)
+
+	listed := make([]string, len(products))
+	for i, product := range products {
+		listed[i] = product.Product
+
+		err = txModel.UpsertProduct(ctx, product)
+		if err != nil {
+			return 0, eris.Wrapf(err, "failed to store product '%s'", product.Product)
+		}
+	}
+
+	delisted, err = txModel.MarkMissingProductsDelisted(ctx, querier.MarkMissingProductsDelistedParams{
+		Exchange: exchange,
+		Listed:   listed,
+	})
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to mark delisted products")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, eris.Wrap(err, "failed to commit products")
+	}
+
+	return delisted, nil
+}
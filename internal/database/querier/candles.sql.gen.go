@@ -8,20 +8,41 @@ package querier
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const clearStagedCandles = `-- name: ClearStagedCandles :exec
+DELETE FROM candles_staging
+`
+
+func (q *Queries) ClearStagedCandles(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, clearStagedCandles)
+	return err
+}
+
 const getCandles = `-- name: GetCandles :many
-SELECT pair, start, price_open, price_close, price_low, price_high, volume FROM candles WHERE pair = $1 AND start >= $2 AND start < $3
+SELECT pair, start, price_open, price_close, price_low, price_high, volume, quote_volume, trade_count, exchange, interval_seconds FROM candles
+WHERE exchange = $1 AND pair = $2 AND interval_seconds = $3
+  AND start >= $4 AND start < $5
 `
 
 type GetCandlesParams struct {
-	Pair      string
-	StartTime time.Time
-	EndTime   time.Time
+	Exchange        string
+	Pair            string
+	IntervalSeconds int32
+	StartTime       time.Time
+	EndTime         time.Time
 }
 
 func (q *Queries) GetCandles(ctx context.Context, arg GetCandlesParams) ([]*Candle, error) {
-	rows, err := q.db.Query(ctx, getCandles, arg.Pair, arg.StartTime, arg.EndTime)
+	rows, err := q.db.Query(ctx, getCandles,
+		arg.Exchange,
+		arg.Pair,
+		arg.IntervalSeconds,
+		arg.StartTime,
+		arg.EndTime,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +58,10 @@ func (q *Queries) GetCandles(ctx context.Context, arg GetCandlesParams) ([]*Cand
 			&i.PriceLow,
 			&i.PriceHigh,
 			&i.Volume,
+			&i.QuoteVolume,
+			&i.TradeCount,
+			&i.Exchange,
+			&i.IntervalSeconds,
 		); err != nil {
 			return nil, err
 		}
@@ -49,23 +74,86 @@ func (q *Queries) GetCandles(ctx context.Context, arg GetCandlesParams) ([]*Cand
 }
 
 const getLatestCandle = `-- name: GetLatestCandle :one
-SELECT start FROM candles WHERE pair = $1
+SELECT start FROM candles
+WHERE exchange = $1 AND pair = $2 AND interval_seconds = $3
 ORDER BY start DESC  LIMIT 1
 `
 
-func (q *Queries) GetLatestCandle(ctx context.Context, pair string) (time.Time, error) {
-	row := q.db.QueryRow(ctx, getLatestCandle, pair)
+type GetLatestCandleParams struct {
+	Exchange        string
+	Pair            string
+	IntervalSeconds int32
+}
+
+func (q *Queries) GetLatestCandle(ctx context.Context, arg GetLatestCandleParams) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getLatestCandle, arg.Exchange, arg.Pair, arg.IntervalSeconds)
 	var start time.Time
 	err := row.Scan(&start)
 	return start, err
 }
 
-type InsertCandlesParams struct {
-	Pair       string
-	Start      time.Time
-	PriceOpen  float64
-	PriceClose float64
-	PriceLow   float64
-	PriceHigh  float64
-	Volume     float64
+const mergeStagedCandles = `-- name: MergeStagedCandles :many
+INSERT INTO candles (
+    exchange, pair, interval_seconds, start,
+    price_open, price_close, price_low, price_high,
+    volume, quote_volume, trade_count
+)
+SELECT DISTINCT ON (exchange, pair, interval_seconds, start)
+    exchange, pair, interval_seconds, start,
+    price_open, price_close, price_low, price_high,
+    volume, quote_volume, trade_count
+FROM candles_staging
+ORDER BY exchange, pair, interval_seconds, start
+ON CONFLICT (exchange, pair, interval_seconds, start) DO UPDATE SET
+    price_open   = EXCLUDED.price_open,
+    price_close  = EXCLUDED.price_close,
+    price_low    = EXCLUDED.price_low,
+    price_high   = EXCLUDED.price_high,
+    volume       = EXCLUDED.volume,
+    quote_volume = EXCLUDED.quote_volume,
+    trade_count  = EXCLUDED.trade_count
+WHERE (
+    candles.price_open, candles.price_close, candles.price_low, candles.price_high,
+    candles.volume, candles.quote_volume, candles.trade_count
+) IS DISTINCT FROM (
+    EXCLUDED.price_open, EXCLUDED.price_close, EXCLUDED.price_low, EXCLUDED.price_high,
+    EXCLUDED.volume, EXCLUDED.quote_volume, EXCLUDED.trade_count
+)
+RETURNING (xmax = 0) AS inserted
+`
+
+// Moves all staged candles into `candles`. Returns one row per candle which
+// is new (true) or which changed (false).
+func (q *Queries) MergeStagedCandles(ctx context.Context) ([]bool, error) {
+	rows, err := q.db.Query(ctx, mergeStagedCandles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []bool
+	for rows.Next() {
+		var inserted bool
+		if err := rows.Scan(&inserted); err != nil {
+			return nil, err
+		}
+		items = append(items, inserted)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+type StageCandlesParams struct {
+	Exchange        string
+	Pair            string
+	IntervalSeconds int32
+	Start           time.Time
+	PriceOpen       pgtype.Numeric
+	PriceClose      pgtype.Numeric
+	PriceLow        pgtype.Numeric
+	PriceHigh       pgtype.Numeric
+	Volume          pgtype.Numeric
+	QuoteVolume     pgtype.Numeric
+	TradeCount      pgtype.Int8
 }
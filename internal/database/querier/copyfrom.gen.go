@@ -9,13 +9,13 @@ import (
 	"context"
 )
 
-// iteratorForInsertCandles implements pgx.CopyFromSource.
-type iteratorForInsertCandles struct {
-	rows                 []InsertCandlesParams
+// iteratorForStageCandles implements pgx.CopyFromSource.
+type iteratorForStageCandles struct {
+	rows                 []StageCandlesParams
 	skippedFirstNextCall bool
 }
 
-func (r *iteratorForInsertCandles) Next() bool {
+func (r *iteratorForStageCandles) Next() bool {
 	if len(r.rows) == 0 {
 		return false
 	}
@@ -27,22 +27,66 @@ func (r *iteratorForInsertCandles) Next() bool {
 	return len(r.rows) > 0
 }
 
-func (r iteratorForInsertCandles) Values() ([]interface{}, error) {
+func (r iteratorForStageCandles) Values() ([]interface{}, error) {
 	return []interface{}{
+		r.rows[0].Exchange,
 		r.rows[0].Pair,
+		r.rows[0].IntervalSeconds,
 		r.rows[0].Start,
 		r.rows[0].PriceOpen,
 		r.rows[0].PriceClose,
 		r.rows[0].PriceLow,
 		r.rows[0].PriceHigh,
 		r.rows[0].Volume,
+		r.rows[0].QuoteVolume,
+		r.rows[0].TradeCount,
 	}, nil
 }
 
-func (r iteratorForInsertCandles) Err() error {
+func (r iteratorForStageCandles) Err() error {
 	return nil
 }
 
-func (q *Queries) InsertCandles(ctx context.Context, arg []InsertCandlesParams) (int64, error) {
-	return q.db.CopyFrom(ctx, []string{"candles"}, []string{"pair", "start", "price_open", "price_close", "price_low", "price_high", "volume"}, &iteratorForInsertCandles{rows: arg})
+func (q *Queries) StageCandles(ctx context.Context, arg []StageCandlesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"candles_staging"}, []string{"exchange", "pair", "interval_seconds", "start", "price_open", "price_close", "price_low", "price_high", "volume", "quote_volume", "trade_count"}, &iteratorForStageCandles{rows: arg})
+}
+
+// iteratorForStageTrades implements pgx.CopyFromSource.
+type iteratorForStageTrades struct {
+	rows                 []StageTradesParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForStageTrades) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForStageTrades) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Exchange,
+		r.rows[0].Pair,
+		r.rows[0].Stream,
+		r.rows[0].TradeID,
+		r.rows[0].Price,
+		r.rows[0].Quantity,
+		r.rows[0].TakerSide,
+		r.rows[0].EventTime,
+		r.rows[0].TradeTime,
+	}, nil
+}
+
+func (r iteratorForStageTrades) Err() error {
+	return nil
+}
+
+func (q *Queries) StageTrades(ctx context.Context, arg []StageTradesParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"trades_staging"}, []string{"exchange", "pair", "stream", "trade_id", "price", "quantity", "taker_side", "event_time", "trade_time"}, &iteratorForStageTrades{rows: arg})
 }
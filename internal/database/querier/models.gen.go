@@ -6,14 +6,99 @@ package querier
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ArbitrageOpportunity struct {
+	Pair         string
+	BuyExchange  string
+	SellExchange string
+	StartedAt    time.Time
+	EndedAt      time.Time
+	MaxSize      float64
+	MaxEdgeBps   float64
+	MaxProfit    float64
+}
+
 type Candle struct {
-	Pair       string
-	Start      time.Time
-	PriceOpen  float64
-	PriceClose float64
-	PriceLow   float64
-	PriceHigh  float64
-	Volume     float64
+	Pair            string
+	Start           time.Time
+	PriceOpen       pgtype.Numeric
+	PriceClose      pgtype.Numeric
+	PriceLow        pgtype.Numeric
+	PriceHigh       pgtype.Numeric
+	Volume          pgtype.Numeric
+	QuoteVolume     pgtype.Numeric
+	TradeCount      pgtype.Int8
+	Exchange        string
+	IntervalSeconds int32
+}
+
+type CandleGap struct {
+	Exchange        string
+	Pair            string
+	IntervalSeconds int32
+	GapStart        time.Time
+	GapEnd          time.Time
+	CheckedAt       time.Time
+}
+
+type CandlesStaging struct {
+	Exchange        string
+	Pair            string
+	IntervalSeconds int32
+	Start           time.Time
+	PriceOpen       pgtype.Numeric
+	PriceClose      pgtype.Numeric
+	PriceLow        pgtype.Numeric
+	PriceHigh       pgtype.Numeric
+	Volume          pgtype.Numeric
+	QuoteVolume     pgtype.Numeric
+	TradeCount      pgtype.Int8
+}
+
+type Listing struct {
+	Exchange     string
+	Product      string
+	FirstCandle  time.Time
+	DiscoveredAt time.Time
+}
+
+type Product struct {
+	Exchange        string
+	Product         string
+	BaseCurrency    string
+	QuoteCurrency   string
+	TickSize        pgtype.Numeric
+	LotSize         pgtype.Numeric
+	MinFunds        pgtype.Numeric
+	Status          string
+	TradingDisabled bool
+	UpdatedAt       time.Time
+	DelistedAt      *time.Time
+}
+
+type Trade struct {
+	Exchange  string
+	Pair      string
+	TradeID   int64
+	Price     pgtype.Numeric
+	Quantity  pgtype.Numeric
+	TakerSide string
+	EventTime time.Time
+	TradeTime time.Time
+	Stream    pgtype.Text
+}
+
+type TradesStaging struct {
+	Exchange  string
+	Pair      string
+	Stream    string
+	TradeID   int64
+	Price     pgtype.Numeric
+	Quantity  pgtype.Numeric
+	TakerSide string
+	EventTime time.Time
+	TradeTime time.Time
 }
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE candles
+    ALTER COLUMN price_open   TYPE double precision,
+    ALTER COLUMN price_close  TYPE double precision,
+    ALTER COLUMN price_low    TYPE double precision,
+    ALTER COLUMN price_high   TYPE double precision,
+    ALTER COLUMN volume       TYPE double precision;
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Store prices and volumes exactly as reported by exchanges.
+ALTER TABLE candles
+    ALTER COLUMN price_open   TYPE NUMERIC,
+    ALTER COLUMN price_close  TYPE NUMERIC,
+    ALTER COLUMN price_low    TYPE NUMERIC,
+    ALTER COLUMN price_high   TYPE NUMERIC,
+    ALTER COLUMN volume       TYPE NUMERIC;
+
+COMMIT;
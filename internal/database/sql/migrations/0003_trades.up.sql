@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TABLE trades
+(
+    exchange    TEXT         NOT NULL,
+    pair        TEXT         NOT NULL,
+
+    -- As assigned by the exchange. IDs of aggregated trades (e.g. Binance's
+    -- `aggTrade`) differ from those of individual trades.
+    trade_id    BIGINT       NOT NULL,
+
+    price       NUMERIC      NOT NULL,
+    quantity    NUMERIC      NOT NULL,
+
+    -- Side of the taker (the order which was filled immediately): `buy` or
+    -- `sell`.
+    taker_side  TEXT         NOT NULL,
+
+    -- When the exchange sent the trade and when it was executed.
+    event_time  TIMESTAMPTZ  NOT NULL,
+    trade_time  TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX trades_pair_time_idx ON trades (exchange, pair, trade_time);
+
+COMMIT;
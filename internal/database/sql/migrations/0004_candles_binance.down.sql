@@ -0,0 +1,13 @@
+BEGIN;
+
+-- Keep only candles which fit the old key.
+DELETE FROM candles WHERE exchange <> 'coinbase';
+
+ALTER TABLE candles
+    DROP CONSTRAINT candles_pkey,
+    ADD PRIMARY KEY (pair, start),
+    DROP COLUMN exchange,
+    DROP COLUMN quote_volume,
+    DROP COLUMN trade_count;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Only provided by some exchanges (e.g. Binance).
+ALTER TABLE candles
+    ADD COLUMN quote_volume  NUMERIC,
+    ADD COLUMN trade_count   BIGINT;
+
+-- Candles of several exchanges are stored from now on. All candles stored so
+-- far are Coinbase's.
+ALTER TABLE candles
+    ADD COLUMN exchange  TEXT  NOT NULL  DEFAULT 'coinbase';
+
+ALTER TABLE candles
+    ALTER COLUMN exchange  DROP DEFAULT,
+    DROP CONSTRAINT candles_pkey,
+    ADD PRIMARY KEY (exchange, pair, start);
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+-- Keep only candles which fit the old key.
+DELETE FROM candles WHERE interval_seconds <> 60;
+
+ALTER TABLE candles
+    DROP CONSTRAINT candles_pkey,
+    ADD PRIMARY KEY (exchange, pair, start),
+    DROP COLUMN interval_seconds;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+-- So far, all candles are 1m-candles.
+ALTER TABLE candles
+    ADD COLUMN interval_seconds  INTEGER  NOT NULL  DEFAULT 60;
+
+ALTER TABLE candles
+    ALTER COLUMN interval_seconds  DROP DEFAULT,
+    DROP CONSTRAINT candles_pkey,
+    ADD PRIMARY KEY (exchange, pair, interval_seconds, start);
+
+COMMIT;
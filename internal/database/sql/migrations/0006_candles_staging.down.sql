@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE candles_staging;
+
+COMMIT;
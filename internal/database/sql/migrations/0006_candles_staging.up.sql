@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Candles are copied into this table before being merged into `candles`.
+-- Rows only live within the transaction which stages them; hence, the table
+-- is always empty from the outside and does not need to be crash-safe.
+CREATE UNLOGGED TABLE candles_staging
+(
+    exchange          TEXT         NOT NULL,
+    pair              TEXT         NOT NULL,
+    interval_seconds  INTEGER      NOT NULL,
+    start             TIMESTAMPTZ  NOT NULL,
+
+    price_open        NUMERIC      NOT NULL,
+    price_close       NUMERIC      NOT NULL,
+    price_low         NUMERIC      NOT NULL,
+    price_high        NUMERIC      NOT NULL,
+
+    volume            NUMERIC      NOT NULL,
+    quote_volume      NUMERIC,
+    trade_count       BIGINT
+);
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+-- Time ranges without candles which were downloaded again but for which the
+-- exchange has no data (e.g. no trades). They are not downloaded again.
+CREATE TABLE candle_gaps
+(
+    exchange          TEXT         NOT NULL,
+    pair              TEXT         NOT NULL,
+    interval_seconds  INTEGER      NOT NULL,
+
+    -- Start of the first and the last missing candle.
+    gap_start         TIMESTAMPTZ  NOT NULL,
+    gap_end           TIMESTAMPTZ  NOT NULL,
+
+    checked_at        TIMESTAMPTZ  NOT NULL,
+
+    PRIMARY KEY (exchange, pair, interval_seconds, gap_start)
+);
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+-- The products (pairs) offered by exchanges.
+CREATE TABLE products
+(
+    exchange          TEXT         NOT NULL,
+    product           TEXT         NOT NULL,
+
+    base_currency     TEXT         NOT NULL,
+    quote_currency    TEXT         NOT NULL,
+
+    -- Minimal increments of prices and amounts, and minimal funds (in the
+    -- quote currency) of an order if known.
+    tick_size         NUMERIC      NOT NULL,
+    lot_size          NUMERIC      NOT NULL,
+    min_funds         NUMERIC,
+
+    -- As reported by the exchange, e.g. `online` or `delisted`.
+    status            TEXT         NOT NULL,
+    trading_disabled  BOOLEAN      NOT NULL,
+
+    updated_at        TIMESTAMPTZ  NOT NULL,
+
+    -- When the product was first seen delisted or missing from the exchange.
+    delisted_at       TIMESTAMPTZ,
+
+    PRIMARY KEY (exchange, product)
+);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- The first candle offered by an exchange for a product, i.e., roughly when
+-- it was listed. Only candles after 2020-01-01 are considered.
+CREATE TABLE listings
+(
+    exchange       TEXT         NOT NULL,
+    product        TEXT         NOT NULL,
+
+    first_candle   TIMESTAMPTZ  NOT NULL,
+    discovered_at  TIMESTAMPTZ  NOT NULL,
+
+    PRIMARY KEY (exchange, product)
+);
+
+COMMIT;
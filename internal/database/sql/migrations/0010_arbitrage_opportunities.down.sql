@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE arbitrage_opportunities;
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- Periods during which buying on one exchange and selling on another was
+-- profitable after taker fees, estimated from the live order books.
+CREATE TABLE arbitrage_opportunities
+(
+    pair           TEXT              NOT NULL,
+    buy_exchange   TEXT              NOT NULL,
+    sell_exchange  TEXT              NOT NULL,
+
+    started_at     TIMESTAMPTZ       NOT NULL,
+    ended_at       TIMESTAMPTZ       NOT NULL,
+
+    -- Largest profitable amount (in the base currency) and largest edge of
+    -- that amount (in bps, net of fees) observed during the period.
+    max_size       DOUBLE PRECISION  NOT NULL,
+    max_edge_bps   DOUBLE PRECISION  NOT NULL,
+
+    -- Largest profit (in the quote currency) observed during the period.
+    max_profit     DOUBLE PRECISION  NOT NULL,
+
+    PRIMARY KEY (pair, buy_exchange, sell_exchange, started_at)
+);
+
+COMMIT;
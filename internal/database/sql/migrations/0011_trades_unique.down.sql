@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE trades_staging;
+
+ALTER TABLE trades DROP CONSTRAINT trades_stream_id_key;
+ALTER TABLE trades DROP COLUMN stream;
+
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+
+-- The stream a trade was received from. Trade IDs are only unique within a
+-- stream since, e.g., Binance's `trade` and `aggTrade` streams number trades
+-- independently. Trades stored before are not attributed to a stream; the
+-- column is null for them, which keeps them apart from all other trades.
+ALTER TABLE trades ADD COLUMN stream TEXT;
+
+-- Trades received twice (e.g. replayed after reconnecting) are stored once.
+ALTER TABLE trades ADD CONSTRAINT trades_stream_id_key UNIQUE (exchange, pair, stream, trade_id);
+
+-- Trades are copied into this table before being merged into `trades`, which
+-- skips known ones. Rows only live within the transaction which stages them;
+-- hence, the table is always empty from the outside and does not need to be
+-- crash-safe.
+CREATE UNLOGGED TABLE trades_staging
+(
+    exchange    TEXT         NOT NULL,
+    pair        TEXT         NOT NULL,
+    stream      TEXT         NOT NULL,
+    trade_id    BIGINT       NOT NULL,
+
+    price       NUMERIC      NOT NULL,
+    quantity    NUMERIC      NOT NULL,
+    taker_side  TEXT         NOT NULL,
+
+    event_time  TIMESTAMPTZ  NOT NULL,
+    trade_time  TIMESTAMPTZ  NOT NULL
+);
+
+COMMIT;
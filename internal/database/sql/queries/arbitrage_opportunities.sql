@@ -0,0 +1,11 @@
+-- name: GetArbitrageOpportunities :many
+SELECT * FROM arbitrage_opportunities
+WHERE pair = $1 AND started_at >= sqlc.arg(start_time) AND started_at < sqlc.arg(end_time)
+ORDER BY started_at, buy_exchange, sell_exchange;
+
+-- name: InsertArbitrageOpportunity :exec
+INSERT INTO arbitrage_opportunities (
+    pair, buy_exchange, sell_exchange,
+    started_at, ended_at,
+    max_size, max_edge_bps, max_profit
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
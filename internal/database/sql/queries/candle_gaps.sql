@@ -0,0 +1,29 @@
+-- name: FindCandleGaps :many
+-- Returns ranges of missing candles between stored ones (from the start of
+-- the first to the start of the last missing candle) which are not known to
+-- be empty.
+SELECT g.gap_start::timestamptz AS gap_start, g.gap_end::timestamptz AS gap_end
+FROM (
+    SELECT
+        start + interval_seconds * INTERVAL '1 second' AS gap_start,
+        LEAD(start) OVER (ORDER BY start) - interval_seconds * INTERVAL '1 second' AS gap_end
+    FROM candles
+    WHERE exchange = $1 AND pair = $2 AND interval_seconds = $3
+) g
+WHERE g.gap_end >= g.gap_start
+  AND NOT EXISTS (
+    SELECT 1 FROM candle_gaps k
+    WHERE k.exchange = $1 AND k.pair = $2 AND k.interval_seconds = $3
+      AND k.gap_start <= g.gap_start AND k.gap_end >= g.gap_end
+  )
+ORDER BY g.gap_start
+LIMIT sqlc.arg(max_gaps);
+
+-- name: RecordCandleGap :exec
+INSERT INTO candle_gaps (
+    exchange, pair, interval_seconds,
+    gap_start, gap_end, checked_at
+) VALUES ($1, $2, $3, $4, $5, now())
+ON CONFLICT (exchange, pair, interval_seconds, gap_start) DO UPDATE SET
+    gap_end    = GREATEST(candle_gaps.gap_end, EXCLUDED.gap_end),
+    checked_at = EXCLUDED.checked_at;
@@ -1,13 +1,50 @@
 -- name: GetCandles :many
-SELECT * FROM candles WHERE pair = $1 AND start >= sqlc.arg(start_time) AND start < sqlc.arg(end_time);
+SELECT * FROM candles
+WHERE exchange = $1 AND pair = $2 AND interval_seconds = $3
+  AND start >= sqlc.arg(start_time) AND start < sqlc.arg(end_time);
 
--- name: InsertCandles :copyfrom
-INSERT INTO candles (
-    pair, start,
+-- name: StageCandles :copyfrom
+INSERT INTO candles_staging (
+    exchange, pair, interval_seconds, start,
     price_open, price_close, price_low, price_high,
-    volume
-) VALUES ($1, $2, $3, $4, $5, $6, $7);
+    volume, quote_volume, trade_count
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
 
 -- name: GetLatestCandle :one
-SELECT start FROM candles WHERE pair = $1
+SELECT start FROM candles
+WHERE exchange = $1 AND pair = $2 AND interval_seconds = $3
 ORDER BY start DESC  LIMIT 1;
+
+-- name: MergeStagedCandles :many
+-- Moves all staged candles into `candles`. Returns one row per candle which
+-- is new (true) or which changed (false).
+INSERT INTO candles (
+    exchange, pair, interval_seconds, start,
+    price_open, price_close, price_low, price_high,
+    volume, quote_volume, trade_count
+)
+SELECT DISTINCT ON (exchange, pair, interval_seconds, start)
+    exchange, pair, interval_seconds, start,
+    price_open, price_close, price_low, price_high,
+    volume, quote_volume, trade_count
+FROM candles_staging
+ORDER BY exchange, pair, interval_seconds, start
+ON CONFLICT (exchange, pair, interval_seconds, start) DO UPDATE SET
+    price_open   = EXCLUDED.price_open,
+    price_close  = EXCLUDED.price_close,
+    price_low    = EXCLUDED.price_low,
+    price_high   = EXCLUDED.price_high,
+    volume       = EXCLUDED.volume,
+    quote_volume = EXCLUDED.quote_volume,
+    trade_count  = EXCLUDED.trade_count
+WHERE (
+    candles.price_open, candles.price_close, candles.price_low, candles.price_high,
+    candles.volume, candles.quote_volume, candles.trade_count
+) IS DISTINCT FROM (
+    EXCLUDED.price_open, EXCLUDED.price_close, EXCLUDED.price_low, EXCLUDED.price_high,
+    EXCLUDED.volume, EXCLUDED.quote_volume, EXCLUDED.trade_count
+)
+RETURNING (xmax = 0) AS inserted;
+
+-- name: ClearStagedCandles :exec
+DELETE FROM candles_staging;
@@ -0,0 +1,10 @@
+-- name: GetListing :one
+SELECT first_candle FROM listings WHERE exchange = $1 AND product = $2;
+
+-- name: SaveListing :exec
+INSERT INTO listings (
+    exchange, product, first_candle, discovered_at
+) VALUES ($1, $2, $3, now())
+ON CONFLICT (exchange, product) DO UPDATE SET
+    first_candle  = EXCLUDED.first_candle,
+    discovered_at = EXCLUDED.discovered_at;
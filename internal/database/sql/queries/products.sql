@@ -0,0 +1,33 @@
+-- name: GetProducts :many
+SELECT * FROM products WHERE exchange = $1 ORDER BY product;
+
+-- name: UpsertProduct :exec
+INSERT INTO products (
+    exchange, product,
+    base_currency, quote_currency,
+    tick_size, lot_size, min_funds,
+    status, trading_disabled,
+    updated_at, delisted_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9,
+    now(), CASE WHEN $8::text = 'delisted' THEN now() END
+)
+ON CONFLICT (exchange, product) DO UPDATE SET
+    base_currency    = EXCLUDED.base_currency,
+    quote_currency   = EXCLUDED.quote_currency,
+    tick_size        = EXCLUDED.tick_size,
+    lot_size         = EXCLUDED.lot_size,
+    min_funds        = EXCLUDED.min_funds,
+    status           = EXCLUDED.status,
+    trading_disabled = EXCLUDED.trading_disabled,
+    updated_at       = EXCLUDED.updated_at,
+    delisted_at      = CASE WHEN EXCLUDED.status = 'delisted'
+                            THEN COALESCE(products.delisted_at, EXCLUDED.delisted_at) END;
+
+-- name: MarkMissingProductsDelisted :execrows
+-- Marks all products of the exchange as delisted which are not listed.
+UPDATE products
+SET status = 'delisted', delisted_at = now(), updated_at = now()
+WHERE exchange = $1
+  AND delisted_at IS NULL
+  AND NOT (product = ANY(sqlc.arg(listed)::text[]));
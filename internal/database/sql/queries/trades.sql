@@ -0,0 +1,29 @@
+-- name: GetTrades :many
+SELECT * FROM trades
+WHERE exchange = $1 AND pair = $2 AND trade_time >= sqlc.arg(start_time) AND trade_time < sqlc.arg(end_time)
+ORDER BY trade_time, trade_id;
+
+-- name: StageTrades :copyfrom
+INSERT INTO trades_staging (
+    exchange, pair, stream, trade_id,
+    price, quantity, taker_side,
+    event_time, trade_time
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: MergeStagedTrades :many
+-- Moves all staged trades into `trades`. Returns one row per new trade.
+INSERT INTO trades (
+    exchange, pair, stream, trade_id,
+    price, quantity, taker_side,
+    event_time, trade_time
+)
+SELECT
+    exchange, pair, stream, trade_id,
+    price, quantity, taker_side,
+    event_time, trade_time
+FROM trades_staging
+ON CONFLICT (exchange, pair, stream, trade_id) DO NOTHING
+RETURNING exchange, pair;
+
+-- name: ClearStagedTrades :exec
+DELETE FROM trades_staging;
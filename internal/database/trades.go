@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database/querier"
+)
+
+func GetTrades(ctx context.Context, exchange, pair string, start, end time.Time) ([]*querier.Trade, error) {
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout)
+	defer cancel()
+
+	res, err := model.GetTrades(ctx, querier.GetTradesParams{
+		Exchange:  exchange,
+		Pair:      pair,
+		StartTime: start,
+		EndTime:   end,
+	})
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to query trades")
+	}
+
+	return res, nil
+}
+
+// Stores the given trades. Already stored trades are skipped; returns the
+// exchange and pair of each new one.
+func InsertTrades(ctx context.Context, trades []querier.StageTradesParams) (inserted []*querier.MergeStagedTradesRow, err error) {
+	if len(trades) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, TransactionTimeout)
+	defer cancel()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	txModel := model.WithTx(tx)
+
+	_, err = txModel.StageTrades(ctx, trades)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to stage trades")
+	}
+
+	inserted, err = txModel.MergeStagedTrades(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to merge trades")
+	}
+
+	err = txModel.ClearStagedTrades(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to clear staged trades")
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to commit trades")
+	}
+
+	return inserted, nil
+}
@@ -0,0 +1,76 @@
+package binance
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/order"
+)
+
+// Returns the path of the file storing the symbol's order book between
+// restarts, or an empty string if storing books is disabled.
+func bookFilePath(symbol string) string {
+	if config.C.OrderBook.SnapshotDir == "" {
+		return ""
+	}
+	return filepath.Join(config.C.OrderBook.SnapshotDir, "binance-"+strings.ToLower(symbol)+".book")
+}
+
+// Loads the order book stored by a previous run. It returns nil if there is
+// none or if it cannot be read; the book is then downloaded instead.
+func loadBookFile(symbol string) *order.Book {
+	path := bookFilePath(symbol)
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		fmt.Printf("Failed to read stored order book '%s': %s\n", path, err)
+		return nil
+	}
+
+	book, err := order.UnmarshalBook(data, config.C.OrderBook.CacheWindow)
+	if err != nil {
+		fmt.Printf("Failed to decode stored order book '%s': %s\n", path, eris.ToString(err, false))
+		return nil
+	}
+
+	return book
+}
+
+// Stores the given book so that the next run can continue from it.
+func saveBookFile(symbol string, book *order.Book) error {
+	path := bookFilePath(symbol)
+	if path == "" {
+		return nil
+	}
+
+	data, err := book.MarshalBinary()
+	if err != nil {
+		return eris.Wrap(err, "failed to encode order book")
+	}
+
+	// Write into a temporary file first to never leave a partial book behind.
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0o644)
+	if err != nil {
+		return eris.Wrapf(err, "failed to write order book to '%s'", tmpPath)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return eris.Wrapf(err, "failed to rename '%s'", tmpPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,310 @@
+package binance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+	"freyr/internal/metrics"
+)
+
+const (
+	// API to receive past candles (klines) of a symbol.
+	spotKlinesURL = "https://api.binance.com/api/v3/klines?symbol=%s&interval=%s&startTime=%d&limit=%d"
+
+	// The maximum number of candles in a single request.
+	klinesPayload = 1000
+
+	// The width of a candle in seconds and as duration.
+	candleInterval int32 = 60
+	candleWidth          = time.Duration(candleInterval) * time.Second
+
+	// The oldest candle we care about.
+	minCandleTime int64 = 1577836800 // 2020-01-01 00:00:00 UTC
+)
+
+// Names of the kline intervals offered by Binance, keyed by their width in
+// seconds.
+var klineIntervals = map[int32]string{
+	60:     "1m",
+	180:    "3m",
+	300:    "5m",
+	900:    "15m",
+	1800:   "30m",
+	3600:   "1h",
+	7200:   "2h",
+	14400:  "4h",
+	21600:  "6h",
+	28800:  "8h",
+	43200:  "12h",
+	86400:  "1d",
+	259200: "3d",
+	604800: "1w",
+}
+
+// An event of a kline stream.
+type klineEvent struct {
+	EType string `json:"e,omitempty,omitzero"` // event type
+	ETime int64  `json:"E,omitempty,omitzero"` // event time (in ms)
+
+	Kline struct {
+		Start int64 `json:"t,omitempty,omitzero"` // start time (in ms)
+		End   int64 `json:"T,omitempty,omitzero"` // close time (in ms)
+
+		Open   string `json:"o,omitempty,omitzero"`
+		Close  string `json:"c,omitempty,omitzero"`
+		High   string `json:"h,omitempty,omitzero"`
+		Low    string `json:"l,omitempty,omitzero"`
+		LastID int64  `json:"L,omitempty,omitzero"` // last trade ID
+
+		Volume      string `json:"v,omitempty,omitzero"`
+		QuoteVolume string `json:"q,omitempty,omitzero"`
+		TradeCount  int64  `json:"n,omitempty,omitzero"`
+
+		// Volumes bought by takers. Declared so that they are not matched
+		// case-insensitively to `v` and `q`.
+		TakerVolume      string `json:"V,omitempty,omitzero"`
+		TakerQuoteVolume string `json:"Q,omitempty,omitzero"`
+
+		// True once the candle is complete.
+		Closed bool `json:"x,omitempty,omitzero"`
+	} `json:"k,omitempty,omitzero"`
+}
+
+// The 1m-kline stream of a single symbol.
+type candleStream struct {
+	inst   exchanges.Instrument
+	symbol string // As used by Binance, e.g. `BTCUSDT`.
+	stream string // Name of the stream, e.g. `btcusdt@kline_1m`.
+}
+
+// Returns nil if candles are not collected for the given symbol.
+func newCandleStream(cfg config.BinanceSymbol) (*candleStream, error) {
+	if !cfg.Candles {
+		return nil, nil
+	}
+
+	inst, symbol, err := parseSymbol(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &candleStream{
+		inst:   inst,
+		symbol: symbol,
+		stream: strings.ToLower(symbol) + "@kline_1m",
+	}, nil
+}
+
+// Converts an event of the stream into a candle. Returns false if the candle
+// is not complete yet.
+func (c *candleStream) parse(data json.RawMessage) (querier.StageCandlesParams, bool, error) {
+	event := klineEvent{}
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		return querier.StageCandlesParams{}, false, eris.Wrapf(err, "failed to unmarshal kline of %s", c.symbol)
+	}
+
+	k := event.Kline
+	if !k.Closed {
+		return querier.StageCandlesParams{}, false, nil
+	}
+
+	candle, err := newCandle(c.inst, candleInterval, k.Start, k.Open, k.Close, k.Low, k.High, k.Volume, k.QuoteVolume, k.TradeCount)
+	return candle, err == nil, err
+}
+
+func newCandle(
+	inst exchanges.Instrument,
+	intervalSeconds int32,
+	start int64,
+	open, close, low, high, volume, quoteVolume string,
+	tradeCount int64,
+) (querier.StageCandlesParams, error) {
+	candle := querier.StageCandlesParams{
+		Exchange:        "binance",
+		Pair:            inst.String(),
+		IntervalSeconds: intervalSeconds,
+		Start:           time.UnixMilli(start),
+		TradeCount:      pgtype.Int8{Int64: tradeCount, Valid: true},
+	}
+
+	for _, field := range []struct {
+		dst   *pgtype.Numeric
+		value string
+	}{
+		{&candle.PriceOpen, open},
+		{&candle.PriceClose, close},
+		{&candle.PriceLow, low},
+		{&candle.PriceHigh, high},
+		{&candle.Volume, volume},
+		{&candle.QuoteVolume, quoteVolume},
+	} {
+		var err error
+		*field.dst, err = database.ParseNumeric(field.value)
+		if err != nil {
+			return querier.StageCandlesParams{}, eris.Wrapf(err, "invalid kline of %s: '%s'", inst, field.value)
+		}
+	}
+
+	return candle, nil
+}
+
+// Stores the candles received via `candleChan`. Missing candles (e.g. after
+// a start or a lost connection) are downloaded first. Runs until the channel
+// is closed.
+func (s *Binance) collectCandles(ctx context.Context, candleChan <-chan querier.StageCandlesParams) {
+	byPair := make(map[string]*candleStream, len(s.candles))
+	latest := make(map[string]time.Time, len(s.candles))
+
+	for _, stream := range s.candles {
+		pair := stream.inst.String()
+		byPair[pair] = stream
+
+		var err error
+		latest[pair], err = s.backfillCandles(ctx, stream, time.Now())
+		if err != nil {
+			reportCandleError(ctx, stream, err)
+		}
+	}
+
+	for candle := range candleChan {
+		stream := byPair[candle.Pair]
+
+		if !candle.Start.After(latest[candle.Pair]) {
+			// Already stored.
+			continue
+		}
+
+		if candle.Start.Sub(latest[candle.Pair]) > candleWidth {
+			ts, err := s.backfillCandles(ctx, stream, candle.Start)
+			latest[candle.Pair] = ts
+			if err != nil {
+				reportCandleError(ctx, stream, err)
+				continue
+			}
+		}
+
+		err := exchanges.StoreCandles(ctx, "binance", stream.inst, candleInterval, []querier.StageCandlesParams{candle})
+		if err != nil {
+			reportCandleError(ctx, stream, err)
+			continue
+		}
+
+		latest[candle.Pair] = candle.Start
+	}
+}
+
+// Prints errors unless they are caused by shutting down.
+func reportCandleError(ctx context.Context, stream *candleStream, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	fmt.Printf("Failed to collect candles of %s: %s\n", stream.symbol, eris.ToString(err, true))
+}
+
+// Downloads and stores all candles after the latest stored one which start
+// before `end`. Returns the start of the latest stored candle afterwards.
+func (s *Binance) backfillCandles(ctx context.Context, stream *candleStream, end time.Time) (time.Time, error) {
+	latest, err := database.GetLatestCandle(ctx, "binance", stream.inst.String(), candleInterval)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	start := time.Unix(minCandleTime, 0)
+	if !latest.IsZero() {
+		start = latest.Add(candleWidth)
+	}
+
+	stored, err := exchanges.CollectCandles(ctx, s, stream.inst, candleInterval, start, end)
+	if !stored.IsZero() {
+		latest = stored
+	}
+
+	return latest, err
+}
+
+func (s *Binance) CandleLimit() int { return klinesPayload }
+
+// Downloads up to `klinesPayload` candles starting at the given time. They
+// are sorted by their start.
+func (s *Binance) DownloadCandles(
+	ctx context.Context,
+	inst exchanges.Instrument,
+	intervalSeconds int32,
+	start time.Time,
+) ([]querier.StageCandlesParams, error) {
+	interval, ok := klineIntervals[intervalSeconds]
+	if !ok {
+		return nil, eris.Errorf("unsupported kline interval: %ds", intervalSeconds)
+	}
+	width := time.Duration(intervalSeconds) * time.Second
+	symbol := s.Symbol(inst)
+
+	metrics.LatestCandleQueried.
+		WithLabelValues("binance", inst.String(), strconv.Itoa(int(intervalSeconds))).
+		Set(float64(start.Add(klinesPayload * width).Unix()))
+
+	url := fmt.Sprintf(spotKlinesURL, symbol, interval, start.UnixMilli(), klinesPayload)
+	data, err := s.get(ctx, url, 10*time.Second)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to download klines of %s", symbol)
+	}
+
+	// Each kline is an array:
+	// [start, open, high, low, close, volume, close time, quote volume, trade count, ...]
+	var rows [][]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&rows)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal response")
+	}
+
+	candles := make([]querier.StageCandlesParams, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < 9 {
+			return nil, eris.Errorf("invalid kline of %s: %v", symbol, row)
+		}
+
+		startMS, ok1 := row[0].(json.Number)
+		tradeCount, ok2 := row[8].(json.Number)
+		if !ok1 || !ok2 {
+			return nil, eris.Errorf("invalid kline of %s: %v", symbol, row)
+		}
+
+		startInt, err1 := startMS.Int64()
+		tradeInt, err2 := tradeCount.Int64()
+		if err := eris.Join(err1, err2); err != nil {
+			return nil, eris.Wrapf(err, "invalid kline of %s: %v", symbol, row)
+		}
+
+		fields := make([]string, 0, 6)
+		for _, i := range []int{1, 4, 3, 2, 5, 7} { // open, close, low, high, volume, quote volume
+			field, ok := row[i].(string)
+			if !ok {
+				return nil, eris.Errorf("invalid kline of %s: %v", symbol, row)
+			}
+			fields = append(fields, field)
+		}
+
+		candle, err := newCandle(inst, intervalSeconds, startInt, fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], tradeInt)
+		if err != nil {
+			return nil, err
+		}
+		candles = append(candles, candle)
+	}
+
+	return candles, nil
+}
@@ -0,0 +1,166 @@
+package binance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+	"freyr/internal/order"
+)
+
+// The order book of a single symbol and the state required to build it from
+// its depth stream and a full order book.
+type depthBook struct {
+	symbol string // As used by Binance, e.g. `BTCUSDT`.
+	stream string // Name of the depth stream, e.g. `btcusdt@depth@100ms`.
+
+	sync *exchanges.BookSync
+
+	// A book stored by a previous run. It avoids downloading the full book if
+	// it is recent enough.
+	storedBook *order.Book
+
+	// True while a requested full book has not been received yet.
+	snapshotPending bool
+}
+
+func newDepthBook(cfg config.BinanceSymbol) (*depthBook, error) {
+	inst, symbol, err := parseSymbol(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := strings.ToLower(symbol) + "@depth"
+	switch cfg.UpdateSpeed {
+	case 100 * time.Millisecond:
+		stream += "@100ms"
+	case time.Second:
+		// Default speed.
+	default:
+		return nil, eris.Errorf("unsupported update speed for %s: %s", symbol, cfg.UpdateSpeed)
+	}
+
+	live, err := exchanges.NewLiveBook("binance", inst, cfg.Precision, cfg.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &depthBook{
+		symbol: symbol,
+		stream: stream,
+		sync:   exchanges.NewBookSync(live),
+	}, nil
+}
+
+// Prepares (re)building the book. The book is not available to readers until
+// it is complete again.
+func (d *depthBook) reset(useStoredBook bool) {
+	d.sync.Reset()
+
+	d.storedBook = nil
+	if useStoredBook {
+		d.storedBook = loadBookFile(d.symbol)
+	}
+}
+
+// Requests a full book via `signalChan` unless one is pending. Since the
+// channel has room for one request per book, this never blocks.
+func (d *depthBook) requestSnapshot(signalChan chan<- string) {
+	if d.snapshotPending {
+		return
+	}
+
+	select {
+	case signalChan <- d.symbol:
+		d.snapshotPending = true
+	default:
+	}
+}
+
+// Processes an update received via the depth stream. A full order book is
+// requested via `requestSnapshot` once the first update is received.
+func (d *depthBook) handleMessage(msg *depthUpdate, requestSnapshot func()) error {
+	live := d.sync.Live()
+	first := !live.Complete() && !d.sync.Buffering()
+
+	asks, bids, err := parseLevels(live.Precision(), msg.Asks, msg.Bids)
+	if err != nil {
+		return eris.Wrapf(err, "failed to parse update for %s", d.symbol)
+	}
+
+	update := exchanges.BookUpdate{
+		Delta:     exchanges.RangeDelta(int64(msg.UF), int64(msg.UL)),
+		Asks:      asks,
+		Bids:      bids,
+		Timestamp: msg.ETime,
+	}
+	err = d.sync.Update(update)
+	if err != nil || !first {
+		return err
+	}
+
+	//
+	// First update: Continue with the stored book or download a full one.
+
+	if d.storedBook != nil && d.sync.Restore(d.storedBook) {
+		fmt.Printf("Continuing with stored order book for %s.\n", d.symbol)
+	} else {
+		// Stored book is missing or too old.
+		requestSnapshot()
+	}
+	d.storedBook = nil
+
+	return nil
+}
+
+// Processes a full order book. Another one is requested if it is older than
+// the first buffered update.
+func (d *depthBook) handleSnapshot(rawOB bookSnapshot, requestSnapshot func()) error {
+	live := d.sync.Live()
+	if live.Complete() {
+		return nil
+	}
+
+	if !d.sync.Buffering() {
+		requestSnapshot()
+		return nil
+	}
+
+	asks, bids, err := parseLevels(live.Precision(), rawOB.Asks, rawOB.Bids)
+	if err != nil {
+		return eris.Wrapf(err, "failed to parse order book for %s", d.symbol)
+	}
+
+	if !d.sync.Snapshot(int64(rawOB.LastUpdateID), asks, bids) {
+		requestSnapshot()
+		return nil
+	}
+
+	fmt.Printf("Order book for %s complete.\n", d.symbol)
+	return nil
+}
+
+// Rebuilds the book after a problem, e.g. a missing update.
+func (d *depthBook) resync(reason string) {
+	d.sync.Resync(reason)
+	d.storedBook = nil
+}
+
+// Removes the book from the registry. If `store` is set, the book is stored
+// for the next run.
+func (d *depthBook) close(store bool) error {
+	live := d.sync.Live()
+	if !live.Complete() {
+		return nil
+	}
+
+	live.Close()
+	if !store {
+		return nil
+	}
+	return saveBookFile(d.symbol, live.Book())
+}
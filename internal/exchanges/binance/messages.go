@@ -8,22 +8,45 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/order"
 )
 
 type subMessage struct {
-	Result any `json:"result,omitempty,omitzero"`
-	ID     int `json:"id,omitempty,omitzero"`
-
-	Stream string `json:"stream,omitempty,omitzero"`
-	Data   *struct {
-		EType string `json:"e,omitempty,omitzero"` // event type (in ms)
-		ETime int64  `json:"E,omitempty,omitzero"` // event time
-		UF    int    `json:"U,omitempty,omitzero"` // first update ID in event
-		UL    int    `json:"u,omitempty,omitzero"` // final update ID in event
-
-		Asks [][]string `json:"a,omitempty,omitzero"`
-		Bids [][]string `json:"b,omitempty,omitzero"`
-	} `json:"data,omitempty,omitzero"`
+	// Response to a request (see `requests.go`).
+	Result json.RawMessage `json:"result,omitempty,omitzero"`
+	Error  *requestError   `json:"error,omitempty,omitzero"`
+	ID     int             `json:"id,omitempty,omitzero"`
+
+	// Event of a stream. Its format depends on the stream.
+	Stream string          `json:"stream,omitempty,omitzero"`
+	Data   json.RawMessage `json:"data,omitempty,omitzero"`
+}
+
+// An event of a depth stream.
+type depthUpdate struct {
+	EType string `json:"e,omitempty,omitzero"` // event type
+	ETime int64  `json:"E,omitempty,omitzero"` // event time (in ms)
+	UF    int    `json:"U,omitempty,omitzero"` // first update ID in event
+	UL    int    `json:"u,omitempty,omitzero"` // final update ID in event
+
+	Asks [][]string `json:"a,omitempty,omitzero"`
+	Bids [][]string `json:"b,omitempty,omitzero"`
+}
+
+// Converts the price levels of a message or snapshot into exact decimals.
+func parseLevels(prec order.Precision, rawAsks, rawBids [][]string) (asks, bids []order.Level, err error) {
+	asks, err = prec.ParseLevels(rawAsks)
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "invalid asks")
+	}
+
+	bids, err = prec.ParseLevels(rawBids)
+	if err != nil {
+		return nil, nil, eris.Wrap(err, "invalid bids")
+	}
+
+	return asks, bids, nil
 }
 
 func (s *Binance) listenForMessages(ctx context.Context, msgChan chan<- []byte) error {
@@ -36,6 +59,11 @@ func (s *Binance) listenForMessages(ctx context.Context, msgChan chan<- []byte)
 
 		_, message, err := s.wsCon.ReadMessage() // blocking
 		if err != nil {
+			if ctx.Err() != nil {
+				// Reading was interrupted.
+				return nil
+			}
+
 			closeErr, ok := err.(*websocket.CloseError)
 			if ok && closeErr.Code == websocket.CloseNormalClosure {
 				// All good.
@@ -44,11 +72,18 @@ func (s *Binance) listenForMessages(ctx context.Context, msgChan chan<- []byte)
 			return eris.Wrap(err, "error reading message")
 		}
 
-		msgChan <- message
+		s.record(recordMessage, message)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case msgChan <- message:
+		}
 	}
 }
 
-func (s *Binance) sendMessage(method string, params ...string) (int, error) {
+// Sends a request with the given ID. Use `request()` to await its response.
+func (s *Binance) sendMessage(msgID int, method string, params ...string) error {
 	//
 	// Convert message to JSON.
 
@@ -57,20 +92,19 @@ func (s *Binance) sendMessage(method string, params ...string) (int, error) {
 
 	jsonMethod, err := json.Marshal(method)
 	if err != nil {
-		return 0, eris.Wrapf(err, "failed to marshal method string: '%s'", method)
+		return eris.Wrapf(err, "failed to marshal method string: '%s'", method)
 	}
 
 	msgBuilder.WriteString(`"method":`)
 	msgBuilder.Write(jsonMethod)
 
-	msgID := int(s.idCtr.Add(1))
 	msgBuilder.WriteString(`,"id":`)
 	msgBuilder.WriteString(strconv.Itoa(msgID))
 
 	if len(params) > 0 {
 		jsonParams, err := json.Marshal(params)
 		if err != nil {
-			return 0, eris.Wrapf(err, "failed to marshal param strings: '%s'", params)
+			return eris.Wrapf(err, "failed to marshal param strings: '%s'", params)
 		}
 
 		msgBuilder.WriteString(`,"params":`)
@@ -82,10 +116,17 @@ func (s *Binance) sendMessage(method string, params ...string) (int, error) {
 	//
 	// Submit message.
 
+	s.conLock.Lock()
+	defer s.conLock.Unlock()
+
+	if s.wsCon == nil {
+		return eris.New("not connected")
+	}
+
 	err = s.wsCon.WriteMessage(websocket.TextMessage, []byte(msgBuilder.String()))
 	if err != nil {
-		return 0, eris.Wrap(err, "failed to submit message")
+		return eris.Wrap(err, "failed to submit message")
 	}
 
-	return msgID, nil
+	return nil
 }
@@ -0,0 +1,105 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/recording"
+	"freyr/internal/utils"
+)
+
+// Kinds of recorded data.
+const (
+	// A raw message received via the websocket.
+	recordMessage recording.Kind = 1
+
+	// A raw full order book received via the REST API.
+	recordSnapshot recording.Kind = 2
+)
+
+// Records the given data. Failures are reported but do not interrupt
+// processing the data.
+func (s *Binance) record(kind recording.Kind, data []byte) {
+	err := s.recorder.Write(recording.Record{Kind: kind, Time: time.Now(), Data: data})
+	if err != nil {
+		fmt.Printf("Failed to record Binance data: %s\n", eris.ToString(err, true))
+	}
+}
+
+// Replays recorded messages and full order books from the files matching the
+// given pattern. Delays between records are reproduced relative to the given
+// speed. Requests for full books are ignored; the recorded ones are provided
+// in their original order instead.
+func replay(
+	ctx context.Context,
+	pattern string,
+	speed float64,
+	msgChan chan<- []byte,
+	signalChan <-chan string,
+	fullOBChan chan<- bookSnapshot,
+) error {
+	defer close(msgChan)
+
+	files, err := recording.Files(pattern)
+	if err != nil {
+		return err
+	} else if len(files) == 0 {
+		return eris.Errorf("no recordings match '%s'", pattern)
+	}
+
+	fmt.Printf("Replaying %d recorded file(s) matching '%s'.\n", len(files), pattern)
+
+	go func() {
+		for range utils.CtxChanIter(ctx, signalChan) {
+			// Discard requests.
+		}
+	}()
+
+	prevTime := time.Time{}
+	for rec, err := range recording.Records(files) {
+		if err != nil {
+			return err
+		}
+
+		if speed > 0 && !prevTime.IsZero() && rec.Time.After(prevTime) {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Duration(float64(rec.Time.Sub(prevTime)) / speed)):
+			}
+		}
+		prevTime = rec.Time
+
+		switch rec.Kind {
+		case recordMessage:
+			select {
+			case <-ctx.Done():
+				return nil
+			case msgChan <- rec.Data:
+			}
+
+		case recordSnapshot:
+			var ob bookSnapshot
+			err = json.Unmarshal(rec.Data, &ob)
+			if err != nil {
+				return eris.Wrap(err, "failed to unmarshal recorded order book")
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case fullOBChan <- ob:
+			}
+
+		default:
+			return eris.Errorf("unknown kind of record: %d", rec.Kind)
+		}
+	}
+
+	fmt.Println("Replay complete.")
+	return nil
+}
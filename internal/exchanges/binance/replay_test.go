@@ -0,0 +1,75 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/config"
+	"freyr/internal/order"
+	"freyr/internal/recording"
+)
+
+// Ensures that a replay does not overwrite the book stored by a live run.
+// Not parallel since it changes the configuration.
+func TestReplayKeepsStoredBook(t *testing.T) {
+	prevReplay, prevSnapshotDir := config.C.Binance.Replay, config.C.OrderBook.SnapshotDir
+	t.Cleanup(func() {
+		config.C.Binance.Replay, config.C.OrderBook.SnapshotDir = prevReplay, prevSnapshotDir
+	})
+
+	dir := t.TempDir()
+	config.C.OrderBook.SnapshotDir = dir
+	config.C.Binance.Replay.Files = filepath.Join(dir, "binance-*")
+	config.C.Binance.Replay.Speed = 0
+
+	stored := []byte("stored by a live run")
+	require.NoError(t, os.WriteFile(bookFilePath("BTCUSDT"), stored, 0o644))
+
+	// The recorded book completes once an update is buffered. Since messages
+	// and books are passed via different channels, the first book may be
+	// handled before the first update; the second book is handled after it.
+	writer, err := recording.NewWriter(dir, "binance", time.Hour)
+	require.NoError(t, err)
+
+	start := time.UnixMilli(1_700_000_000_000)
+	snapshot, err := json.Marshal(bookSnapshot{
+		Symbol:       "BTCUSDT",
+		LastUpdateID: 10,
+		Asks:         [][]string{{"101.00", "1.00000"}},
+		Bids:         [][]string{{"99.00", "1.00000"}},
+	})
+	require.NoError(t, err)
+	for i, rec := range []recording.Record{
+		{Kind: recordMessage, Data: []byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1700000000000,"U":11,"u":11,"a":[["102.00","2.00000"]]}}`)},
+		{Kind: recordSnapshot, Data: snapshot},
+		{Kind: recordMessage, Data: []byte(`{"stream":"btcusdt@depth@100ms","data":{"e":"depthUpdate","E":1700000000001,"U":12,"u":12,"b":[["98.00","2.00000"]]}}`)},
+		{Kind: recordSnapshot, Data: snapshot},
+	} {
+		rec.Time = start.Add(time.Duration(i) * time.Millisecond)
+		require.NoError(t, writer.Write(rec))
+	}
+	require.NoError(t, writer.Close())
+
+	book, err := newDepthBook(config.BinanceSymbol{
+		Symbol:      "BTCUSDT",
+		Pair:        "btc-usdt",
+		Precision:   order.Precision{Price: 2, Amount: 5},
+		Granularity: "0.01",
+		UpdateSpeed: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	s := &Binance{books: []*depthBook{book}}
+	require.NoError(t, s.Run(context.Background()))
+	require.True(t, book.sync.Live().Complete())
+
+	data, err := os.ReadFile(bookFilePath("BTCUSDT"))
+	require.NoError(t, err)
+	require.Equal(t, stored, data)
+}
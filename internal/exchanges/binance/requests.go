@@ -0,0 +1,163 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+)
+
+// How long to wait for the response to a request sent via the websocket.
+const requestTimeout = 10 * time.Second
+
+// An error returned by Binance in response to a request, e.g. for an invalid
+// stream name or after exceeding the rate limit.
+type requestError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("error %d: %s", e.Code, e.Msg)
+}
+
+type requestResult struct {
+	result json.RawMessage
+	err    error
+}
+
+// Requests sent via the websocket which still await their response, by ID.
+type pendingRequests struct {
+	lock     sync.Mutex
+	requests map[int]chan requestResult
+}
+
+func (p *pendingRequests) add(id int) <-chan requestResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.requests == nil {
+		p.requests = map[int]chan requestResult{}
+	}
+
+	resChan := make(chan requestResult, 1)
+	p.requests[id] = resChan
+	return resChan
+}
+
+func (p *pendingRequests) remove(id int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.requests, id)
+}
+
+// Passes a response to the request with the same ID. Returns false if there
+// is no such request, e.g. because it timed out or is part of a replay.
+func (p *pendingRequests) resolve(msg *subMessage) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	resChan, ok := p.requests[msg.ID]
+	if !ok {
+		return false
+	}
+	delete(p.requests, msg.ID)
+
+	if msg.Error != nil {
+		resChan <- requestResult{err: msg.Error}
+	} else {
+		resChan <- requestResult{result: msg.Result}
+	}
+	return true
+}
+
+// Fails all pending requests, e.g. once the connection is closed.
+func (p *pendingRequests) failAll(err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for id, resChan := range p.requests {
+		resChan <- requestResult{err: err}
+		delete(p.requests, id)
+	}
+}
+
+// Sends a request via the websocket and waits for its response. Error
+// responses are returned as `*requestError`.
+func (s *Binance) request(ctx context.Context, method string, params ...string) (json.RawMessage, error) {
+	msgID := int(s.idCtr.Add(1))
+
+	// Register before sending: The response may arrive immediately.
+	resChan := s.pending.add(msgID)
+	defer s.pending.remove(msgID)
+
+	err := s.sendMessage(msgID, method, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(requestTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return nil, eris.Wrapf(ctx.Err(), "%s (id %d) cancelled", method, msgID)
+	case <-timer.C:
+		return nil, eris.Errorf("no response to %s (id %d) within %s", method, msgID, requestTimeout)
+	case res := <-resChan:
+		if res.err != nil {
+			return nil, eris.Wrapf(res.err, "%s (id %d) failed", method, msgID)
+		}
+		return res.result, nil
+	}
+}
+
+// Subscribes to the given streams.
+func (s *Binance) subscribe(ctx context.Context, streams ...string) error {
+	_, err := s.request(ctx, "SUBSCRIBE", streams...)
+	return err
+}
+
+// Returns the streams the websocket is currently subscribed to.
+func (s *Binance) listSubscriptions(ctx context.Context) ([]string, error) {
+	result, err := s.request(ctx, "LIST_SUBSCRIPTIONS")
+	if err != nil {
+		return nil, err
+	}
+
+	var streams []string
+	err = json.Unmarshal(result, &streams)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal subscriptions")
+	}
+
+	return streams, nil
+}
+
+// Subscribes to the given streams and verifies that Binance actually
+// delivers all of them.
+func (s *Binance) subscribeAndVerify(ctx context.Context, streams ...string) error {
+	err := s.subscribe(ctx, streams...)
+	if err != nil {
+		return err
+	}
+
+	active, err := s.listSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range streams {
+		if !slices.Contains(active, stream) {
+			return eris.Errorf("not subscribed to %s (active: %v)", stream, active)
+		}
+	}
+
+	fmt.Printf("Subscribed to %v.\n", active)
+	return nil
+}
@@ -0,0 +1,105 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/utils"
+)
+
+const (
+	// Binance allows a request weight of 6000 per minute; downloading klines
+	// weighs 2.
+	restRateLimit = 20
+	restRateBurst = 40
+
+	// How often a request is attempted before giving up.
+	restMaxAttempts = 5
+)
+
+// An unsuccessful response of Binance's REST API.
+type statusError struct {
+	statusCode int
+
+	// How long Binance asks to wait before retrying (0 if unknown).
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("invalid status code: %d", e.statusCode)
+}
+
+// True if repeating the request may succeed, i.e., after exceeding the rate
+// limit or after a server error. Banned clients (418) are not retried since
+// retrying extends the ban.
+func (e *statusError) retryable() bool {
+	return e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+}
+
+// Requests the given URL and returns the response body. Requests obey the
+// rate limit shared by all symbols. Failures which may be temporary are
+// retried with increasing delays. Each attempt times out after `timeout`.
+func (s *Binance) get(ctx context.Context, url string, timeout time.Duration) ([]byte, error) {
+	backoff := utils.Backoff{Min: 500 * time.Millisecond, Max: 30 * time.Second}
+
+	for attempt := 1; ; attempt++ {
+		if !s.limiter.Wait(ctx) {
+			return nil, eris.Wrap(ctx.Err(), "request cancelled")
+		}
+
+		body, err := s.getOnce(ctx, url, timeout)
+		if err == nil {
+			return body, nil
+		}
+
+		delay := backoff.Next()
+		statusErr := &statusError{}
+		if errors.As(err, &statusErr) {
+			if !statusErr.retryable() {
+				return nil, err
+			}
+			delay = max(delay, statusErr.retryAfter)
+		}
+
+		if attempt >= restMaxAttempts || ctx.Err() != nil {
+			return nil, eris.Wrapf(err, "request failed after %d attempt(s)", attempt)
+		}
+
+		if !utils.Sleep(ctx, delay) {
+			return nil, err
+		}
+	}
+}
+
+func (s *Binance) getOnce(ctx context.Context, url string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to build request")
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to execute request")
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &statusError{statusCode: res.StatusCode, retryAfter: utils.RetryAfter(res)}
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read response")
+	}
+
+	return body, nil
+}
@@ -0,0 +1,55 @@
+package binance
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/exchanges"
+	"freyr/internal/utils"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Ensures that rate-limited requests are retried while client errors are not.
+func TestDownloadCandlesRetry(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		statuses []int
+		requests int
+		fails    bool
+	}{
+		{[]int{http.StatusTooManyRequests, http.StatusOK}, 2, false},
+		{[]int{http.StatusBadRequest, http.StatusOK}, 1, true},
+	} {
+		requests := 0
+		s := &Binance{
+			httpClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				status := tc.statuses[requests]
+				requests++
+				body := `[[1700000000000,"100.0","101.0","99.0","100.5","2.0",1700000059999,"201.0",12,"1.0","100.0","0"]]`
+				return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+			})},
+			limiter: utils.NewRateLimiter(1000, 1000),
+		}
+
+		inst := exchanges.Instrument{Base: "btc", Quote: "usdt"}
+		candles, err := s.DownloadCandles(context.Background(), inst, candleInterval, time.UnixMilli(1_700_000_000_000))
+		require.Equal(t, tc.requests, requests)
+		if tc.fails {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Len(t, candles, 1)
+		require.Equal(t, int64(12), candles[0].TradeCount.Int64)
+	}
+}
@@ -3,16 +3,20 @@ package binance
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/risingwavelabs/eris"
 
-	"freyr/internal/metrics"
-	"freyr/internal/order"
+	"freyr/internal/config"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+	"freyr/internal/recording"
 	"freyr/internal/utils"
 )
 
@@ -21,28 +25,281 @@ const (
 	// See https://developers.binance.com/docs/binance-spot-api-docs/web-socket-streams.
 	spotSocketURL = "wss://stream.binance.com/stream"
 
-	// API to receive the current order book.
-	spotSnapshotURL = "https://api.binance.com/api/v3/depth?symbol=BTCUSDT&limit=5000"
+	// API to receive the current order book of a symbol.
+	spotSnapshotURL = "https://api.binance.com/api/v3/depth?symbol=%s&limit=5000"
 )
 
 type Binance struct {
+	// Symbols of the configured instruments.
+	symbols map[exchanges.Instrument]string
+
+	// Used for requests to the REST API except for full order books.
+	httpClient *http.Client
+	limiter    *utils.RateLimiter
+
 	wsCon *websocket.Conn
 	idCtr atomic.Int64
+
+	// Requests awaiting a response on the current connection.
+	pending pendingRequests
+
+	// Guards replacing `wsCon` and writing to it.
+	conLock sync.Mutex
+
+	// Records all received data (nil if disabled).
+	recorder *recording.Writer
+
+	// One order book per configured symbol.
+	books []*depthBook
+
+	// Trade streams of the symbols for which trades are collected.
+	trades []*tradeStream
+
+	// Passes trades to the goroutine writing them into the database (nil if
+	// trades are not stored).
+	tradeChan chan querier.StageTradesParams
+
+	// Kline streams of the symbols for which candles are collected.
+	candles []*candleStream
+
+	// Passes complete candles to the goroutine storing them (nil if candles
+	// are not stored).
+	candleChan chan querier.StageCandlesParams
+}
+
+var _ exchanges.CandleHistory = (*Binance)(nil)
+
+func (s *Binance) Name() string  { return "Binance Websocket" }
+func (s *Binance) Venue() string { return "binance" }
+
+// Binance names symbols like `BTCUSDT` unless configured otherwise.
+func (s *Binance) Symbol(inst exchanges.Instrument) string {
+	if symbol, ok := s.symbols[inst]; ok {
+		return symbol
+	}
+	return inst.Join("")
+}
+
+// Returns the instrument and the symbol of the given configuration. The
+// symbol is derived from the pair if it is not configured.
+func parseSymbol(cfg config.BinanceSymbol) (exchanges.Instrument, string, error) {
+	inst, err := exchanges.ParseInstrument(cfg.Pair)
+	if err != nil {
+		return exchanges.Instrument{}, "", eris.Wrapf(err, "invalid pair of '%s'", cfg.Symbol)
+	}
+
+	symbol := cfg.Symbol
+	if symbol == "" {
+		symbol = inst.Join("")
+	}
+
+	return inst, symbol, nil
 }
 
-func (s *Binance) Name() string                 { return "Binance Websocket" }
-func (s *Binance) Init(_ context.Context) error { return nil }
+func (s *Binance) Init(_ context.Context) error {
+	s.httpClient = &http.Client{}
+	s.limiter = utils.NewRateLimiter(restRateLimit, restRateBurst)
+
+	s.symbols = make(map[exchanges.Instrument]string, len(config.C.Binance.Symbols))
+	s.books = make([]*depthBook, 0, len(config.C.Binance.Symbols))
+	for _, symbolCfg := range config.C.Binance.Symbols {
+		inst, symbol, err := parseSymbol(symbolCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid symbol configuration")
+		}
+		s.symbols[inst] = symbol
+
+		book, err := newDepthBook(symbolCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid symbol configuration")
+		}
+		s.books = append(s.books, book)
+
+		trades, err := newTradeStream(symbolCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid symbol configuration")
+		}
+		if trades != nil {
+			s.trades = append(s.trades, trades)
+		}
+
+		candles, err := newCandleStream(symbolCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid symbol configuration")
+		}
+		if candles != nil {
+			s.candles = append(s.candles, candles)
+		}
+	}
+
+	if len(s.books) == 0 {
+		return eris.New("no symbols configured")
+	}
+
+	recordCfg := config.C.Binance.Record
+	if recordCfg.Dir == "" || config.C.Binance.Replay.Files != "" {
+		return nil
+	}
+
+	var err error
+	s.recorder, err = recording.NewWriter(recordCfg.Dir, "binance", recordCfg.RotateInterval)
+	if err != nil {
+		return eris.Wrap(err, "failed to create recorder")
+	}
+
+	return nil
+}
 
 func (s *Binance) Run(ctx context.Context) (err error) {
+	// Replays always start from recorded books. Books built from them are
+	// not stored since they are older than those of live runs.
+	replaying := config.C.Binance.Replay.Files != ""
+
+	defer func() {
+		for _, book := range s.books {
+			err = eris.Join(err, book.close(!replaying))
+		}
+		err = eris.Join(err, s.recorder.Close())
+	}()
+
+	for _, book := range s.books {
+		book.reset(!replaying)
+	}
+
+	// Trades of replays are already stored.
+	if len(s.trades) > 0 && !replaying {
+		s.tradeChan = make(chan querier.StageTradesParams, exchanges.TradeBatchSize)
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			exchanges.WriteTrades(context.WithoutCancel(ctx), s.tradeChan)
+		}()
+
+		defer func() {
+			close(s.tradeChan)
+			<-done
+		}()
+	}
+
+	if len(s.candles) > 0 && !replaying {
+		s.candleChan = make(chan querier.StageCandlesParams, len(s.candles))
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			s.collectCandles(ctx, s.candleChan)
+		}()
+
+		defer func() {
+			close(s.candleChan)
+			<-done
+		}()
+	}
+
+	backoff := utils.Backoff{Min: time.Second, Max: 2 * time.Minute}
+
+	for {
+		start := time.Now()
+		err := s.runSession(ctx)
+		if ctx.Err() != nil || replaying {
+			return err
+		}
+
+		//
+		// Connection lost: Reconnect and rebuild all books.
+
+		reason := "disconnected"
+		if err != nil {
+			reason = "connection_error"
+			fmt.Printf("Lost connection to Binance: %s\n", eris.ToString(err, true))
+		} else {
+			fmt.Println("Binance closed the connection.")
+		}
+
+		for _, book := range s.books {
+			book.resync(reason)
+		}
+
+		if time.Since(start) > time.Minute {
+			backoff.Reset()
+		}
+		delay := backoff.Next()
+
+		fmt.Printf("Reconnecting to Binance in %s.\n", delay)
+		if !utils.Sleep(ctx, delay) {
+			return nil
+		}
+	}
+}
+
+// Receives data from Binance (or from a recording) until the connection ends
+// and builds the order books from it.
+func (s *Binance) runSession(ctx context.Context) (err error) {
+	//
+	// Goroutine which provides messages and full order books, either from
+	// Binance or from a recording.
+
+	wg := sync.WaitGroup{}
+	var sourceErr error
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Each book has at most one pending request for a full book. Requests of
+	// previous sessions are lost.
+	signalChan := make(chan string, len(s.books))
+	for _, book := range s.books {
+		book.snapshotPending = false
+	}
+	fullOBChan := make(chan bookSnapshot, 1)
+	msgChan := make(chan []byte, 1)
+
+	defer func() {
+		cancel()
+		wg.Wait()
+		err = eris.Join(err, sourceErr)
+	}()
+
+	replayCfg := config.C.Binance.Replay
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if replayCfg.Files != "" {
+			sourceErr = replay(ctx, replayCfg.Files, replayCfg.Speed, msgChan, signalChan, fullOBChan)
+			if sourceErr != nil {
+				cancel()
+			}
+			return
+		}
+
+		defer cancel()
+		sourceErr = s.runLive(ctx, msgChan, signalChan, fullOBChan)
+	}()
+
+	return s.buildBooks(ctx, msgChan, signalChan, fullOBChan)
+}
+
+// Connects to Binance and forwards all received messages. Full order books
+// are downloaded whenever requested via `signalChan`.
+func (s *Binance) runLive(
+	ctx context.Context,
+	msgChan chan<- []byte,
+	signalChan <-chan string,
+	fullOBChan chan<- bookSnapshot,
+) (err error) {
 	//
 	// Connect.
 
-	var httpRes *http.Response
-	s.wsCon, httpRes, err = websocket.DefaultDialer.DialContext(ctx, spotSocketURL, nil)
+	wsCon, httpRes, err := websocket.DefaultDialer.DialContext(ctx, spotSocketURL, nil)
 	if err != nil {
 		return eris.Wrap(err, "failed to start websocket")
 	}
 
+	s.conLock.Lock()
+	s.wsCon = wsCon
+	s.conLock.Unlock()
+
 	fmt.Printf("Connected to Binance via %s.\n", spotSocketURL)
 
 	//
@@ -52,14 +309,22 @@ func (s *Binance) Run(ctx context.Context) (err error) {
 	var listenErr, fetchErr error
 	ctx, cancel := context.WithCancel(ctx)
 
-	signalChan := make(chan struct{}, 1)
-	fullOBChan := make(chan bookSnapshot, 1)
-	msgChan := make(chan []byte, 1)
-
 	defer func() {
 		cancel()
+
+		// Interrupt reading (if still running) without closing the connection.
+		_ = wsCon.SetReadDeadline(time.Now())
 		wg.Wait()
-		err = eris.Join(err, listenErr, fetchErr, httpRes.Body.Close())
+
+		s.conLock.Lock()
+		defer s.conLock.Unlock()
+
+		// Clean close. Fails if the connection is already lost.
+		_ = wsCon.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		s.wsCon = nil
+		s.pending.failAll(eris.New("connection closed"))
+
+		err = eris.Join(err, listenErr, fetchErr, httpRes.Body.Close(), wsCon.Close())
 	}()
 
 	wg.Add(1)
@@ -76,27 +341,64 @@ func (s *Binance) Run(ctx context.Context) (err error) {
 		fetchErr = s.fetchFullOrderBook(ctx, signalChan, fullOBChan)
 	}()
 
-	//
-	// Build order book.
+	// Subscribe to all depth, trade, and kline streams.
+	streams := make([]string, 0, len(s.books)+len(s.trades)+len(s.candles))
+	for _, book := range s.books {
+		streams = append(streams, book.stream)
+	}
+	for _, trades := range s.trades {
+		streams = append(streams, trades.stream)
+	}
+	for _, candles := range s.candles {
+		streams = append(streams, candles.stream)
+	}
 
-	// Send subscription message.
-	_, err = s.sendMessage("SUBSCRIBE", "btcusdt@depth@100ms")
+	err = s.subscribeAndVerify(ctx, streams...)
+	if err != nil {
+		return eris.Wrap(err, "failed to subscribe")
+	}
 
-	minUpdateVersion := 0
-	maxUpdateVersion := 0
-	msgBuffer := []*subMessage{}
+	<-ctx.Done()
+	return nil
+}
 
-	snapshotVersion := 0
-	orderBook := order.NewBook(1.0)
+// Builds the order books from the given messages and full order books. A
+// full book of a symbol is requested via `signalChan` once the first message
+// for it is received. Books with invalid updates are rebuilt. Trades and
+// candles are passed on to be stored.
+func (s *Binance) buildBooks(
+	ctx context.Context,
+	msgChan <-chan []byte,
+	signalChan chan<- string,
+	fullOBChan <-chan bookSnapshot,
+) error {
+	byStream := make(map[string]*depthBook, len(s.books))
+	bySymbol := make(map[string]*depthBook, len(s.books))
+
+	for _, book := range s.books {
+		byStream[book.stream] = book
+		bySymbol[book.symbol] = book
+	}
 
-	for obComplete := false; !obComplete; {
+	tradesByStream := make(map[string]*tradeStream, len(s.trades))
+	for _, trades := range s.trades {
+		tradesByStream[trades.stream] = trades
+	}
+
+	candlesByStream := make(map[string]*candleStream, len(s.candles))
+	for _, candles := range s.candles {
+		candlesByStream[candles.stream] = candles
+	}
+
+	for {
 		select {
 		case <-ctx.Done():
 			return nil
 
 		case rawMsg, more := <-msgChan:
 			if !more {
-				return eris.Errorf("msgChan closed")
+				// End of replay.
+				return nil
 			}
 
 			msg := &subMessage{}
@@ -105,97 +407,118 @@ func (s *Binance) Run(ctx context.Context) (err error) {
 				return eris.Wrap(err, "failed to unmarshal message")
 			}
 
-			if msg.Data == nil {
-				// Ignore for now.
+			if len(msg.Data) == 0 {
+				// Response to a request. Unknown IDs belong to requests
+				// which timed out or were sent during a recording.
+				if msg.ID != 0 {
+					s.pending.resolve(msg)
+				}
 				continue
 			}
 
-			if minUpdateVersion == 0 {
-				// First update: Start downloading full book.
-				signalChan <- struct{}{}
-				minUpdateVersion = msg.Data.UF
-				maxUpdateVersion = msg.Data.UF - 1 // Pretend we have the previous already.
+			if trades, ok := tradesByStream[msg.Stream]; ok {
+				s.handleTrade(ctx, trades, msg.Data)
+				continue
+			}
+			if candles, ok := candlesByStream[msg.Stream]; ok {
+				s.handleKline(candles, msg.Data)
+				continue
 			}
 
-			if msg.Data.UF != maxUpdateVersion+1 {
-				return eris.Errorf(
-					"invalid ob update version: expected %d, actual %d",
-					maxUpdateVersion+1, msg.Data.UF,
-				)
+			book, ok := byStream[msg.Stream]
+			if !ok {
+				continue
 			}
 
-			maxUpdateVersion = msg.Data.UL
-			msgBuffer = append(msgBuffer, msg)
+			update := &depthUpdate{}
+			err = json.Unmarshal(msg.Data, update)
+			if err != nil {
+				resyncAfter(book, eris.Wrap(err, "failed to unmarshal depth update"))
+				continue
+			}
+
+			err = book.handleMessage(update, func() { book.requestSnapshot(signalChan) })
+			if err != nil {
+				resyncAfter(book, err)
+			}
 
 		case rawOB, more := <-fullOBChan:
 			if !more {
 				return eris.Errorf("fullOBChan closed")
 			}
 
-			if minUpdateVersion == 0 || minUpdateVersion > rawOB.LastUpdateID+1 {
-				signalChan <- struct{}{}
+			book, ok := bySymbol[rawOB.Symbol]
+			if !ok && len(s.books) == 1 {
+				// Recordings made before supporting several symbols.
+				book, ok = s.books[0], true
+			}
+			if !ok {
 				continue
 			}
 
-			orderBook.Update(rawOB.Asks, rawOB.Bids, -1)
-
-			obComplete = true
-			snapshotVersion = rawOB.LastUpdateID
+			book.snapshotPending = false
+			err := book.handleSnapshot(rawOB, func() { book.requestSnapshot(signalChan) })
+			if err != nil {
+				resyncAfter(book, err)
+			}
 		}
 	}
+}
 
-	//
-	// Merge updates and snapshot.
-
-	for _, msg := range msgBuffer {
-		if msg.Data.UL <= snapshotVersion {
-			continue
-		}
-
-		orderBook.Update(msg.Data.Asks, msg.Data.Bids, msg.Data.ETime)
+// Passes a trade on to be stored. Invalid trades are reported and skipped.
+func (s *Binance) handleTrade(ctx context.Context, trades *tradeStream, data json.RawMessage) {
+	if s.tradeChan == nil {
+		return
 	}
-	msgBuffer = nil //nolint: ineffassign
-
-	fmt.Println("Order book complete.")
 
-	//
-	// Continue listening to updates.
-
-	for rawMsg := range utils.CtxChanIter(ctx, msgChan) {
-		var msg subMessage
-		err := json.Unmarshal(rawMsg, &msg)
-		if err != nil {
-			return eris.Wrap(err, "failed to unmarshal message")
-		}
+	trade, err := trades.parse(data)
+	if err != nil {
+		fmt.Printf("Skipping trade: %s\n", eris.ToString(err, false))
+		return
+	}
 
-		if msg.Data == nil {
-			// Ignore for now.
-			continue
-		}
+	select {
+	case <-ctx.Done():
+	case s.tradeChan <- trade:
+	}
+}
 
-		// Verify version.
-		if msg.Data.UF != maxUpdateVersion+1 {
-			return eris.Errorf(
-				"invalid ob update version: expected %d, actual %d",
-				maxUpdateVersion+1, msg.Data.UF,
-			)
-		}
+// Passes a complete candle on to be stored. Candles are dropped while the
+// collector is busy downloading missing ones; it downloads them later.
+func (s *Binance) handleKline(candles *candleStream, data json.RawMessage) {
+	if s.candleChan == nil {
+		return
+	}
 
-		//
-		// Process updates.
+	candle, complete, err := candles.parse(data)
+	if err != nil {
+		fmt.Printf("Skipping kline: %s\n", eris.ToString(err, false))
+		return
+	} else if !complete {
+		return
+	}
 
-		metrics.OrderBookUpdates.
-			WithLabelValues("binance", "btc-usdt").
-			Add(float64(len(msg.Data.Asks) + len(msg.Data.Bids)))
+	select {
+	case s.candleChan <- candle:
+	default:
+	}
+}
 
-		orderBook.Update(msg.Data.Asks, msg.Data.Bids, msg.Data.ETime)
-		maxUpdateVersion = msg.Data.UL
+// Rebuilds the given book after it failed to process data. Resyncs are only
+// counted by reason; logging each one would flood the output during outages.
+func resyncAfter(book *depthBook, err error) {
+	reason := "invalid_data"
+	if errors.Is(err, exchanges.ErrSequenceGap) {
+		reason = "sequence_gap"
 	}
 
-	return nil
+	book.resync(reason)
 }
 
 func (s *Binance) Stop() error {
+	s.conLock.Lock()
+	defer s.conLock.Unlock()
+
 	if s.wsCon == nil {
 		return nil
 	}
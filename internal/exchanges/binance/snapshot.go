@@ -3,6 +3,7 @@ package binance
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -13,53 +14,81 @@ import (
 )
 
 type bookSnapshot struct {
+	// Not part of Binance's response. Added to identify the book.
+	Symbol string `json:"symbol,omitempty,omitzero"`
+
 	LastUpdateID int        `json:"lastUpdateId,omitempty,omitzero"`
 	Asks         [][]string `json:"asks,omitempty,omitzero"`
 	Bids         [][]string `json:"bids,omitempty,omitzero"`
 }
 
+// Downloads the full order book of each symbol received via `signalChan`.
 func (s *Binance) fetchFullOrderBook(
 	ctx context.Context,
-	signalChan <-chan struct{},
+	signalChan <-chan string,
 	fullOBChan chan<- bookSnapshot,
 ) error {
 	defer close(fullOBChan)
 
-	for range utils.CtxChanIter(ctx, signalChan) {
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
-
-		req, err := http.NewRequestWithContext(
-			ctx,
-			http.MethodGet,
-			spotSnapshotURL,
-			nil,
-		)
+	for symbol := range utils.CtxChanIter(ctx, signalChan) {
+		ob, err := s.downloadFullOrderBook(ctx, symbol)
 		if err != nil {
-			return eris.Wrap(err, "failed to build request")
+			return err
 		}
 
-		res, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return eris.Wrap(err, "failed to execute request")
-		} else if res.StatusCode != http.StatusOK {
-			return eris.Errorf("invalid status code: %d", res.StatusCode)
+		select {
+		case <-ctx.Done():
+			return nil
+		case fullOBChan <- ob:
 		}
-		defer func() { _ = res.Body.Close() }()
+	}
 
-		data, err := io.ReadAll(res.Body)
-		if err != nil {
-			return eris.Wrap(err, "failed to read response")
-		}
+	return nil
+}
+
+func (s *Binance) downloadFullOrderBook(ctx context.Context, symbol string) (bookSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		fmt.Sprintf(spotSnapshotURL, symbol),
+		nil,
+	)
+	if err != nil {
+		return bookSnapshot{}, eris.Wrap(err, "failed to build request")
+	}
 
-		var ob bookSnapshot
-		err = json.Unmarshal(data, &ob)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return bookSnapshot{}, eris.Wrap(err, "failed to execute request")
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return bookSnapshot{}, eris.Errorf("invalid status code: %d", res.StatusCode)
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return bookSnapshot{}, eris.Wrap(err, "failed to read response")
+	}
+
+	var ob bookSnapshot
+	err = json.Unmarshal(data, &ob)
+	if err != nil {
+		return bookSnapshot{}, eris.Wrap(err, "failed to unmarshal response")
+	}
+	ob.Symbol = symbol
+
+	if s.recorder != nil {
+		data, err = json.Marshal(ob)
 		if err != nil {
-			return eris.Wrap(err, "failed to unmarshal response")
+			return bookSnapshot{}, eris.Wrap(err, "failed to marshal book for recording")
 		}
-
-		fullOBChan <- ob
+		s.record(recordSnapshot, data)
 	}
 
-	return nil
+	return ob, nil
 }
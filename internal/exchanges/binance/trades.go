@@ -0,0 +1,103 @@
+package binance
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+)
+
+// An event of a trade or aggregated trade stream.
+type tradeEvent struct {
+	EType string `json:"e,omitempty,omitzero"` // event type
+	ETime int64  `json:"E,omitempty,omitzero"` // event time (in ms)
+	TTime int64  `json:"T,omitempty,omitzero"` // trade time (in ms)
+
+	TradeID int64 `json:"t,omitempty,omitzero"` // trade ID (trade stream)
+	AggID   int64 `json:"a,omitempty,omitzero"` // aggregate trade ID (aggTrade stream)
+
+	Price    string `json:"p,omitempty,omitzero"`
+	Quantity string `json:"q,omitempty,omitzero"`
+
+	BuyerMaker bool `json:"m,omitempty,omitzero"`
+
+	// Deprecated by Binance. Declared so that it is not matched to `m`, which
+	// `encoding/json` would do since it matches keys case-insensitively.
+	Ignore bool `json:"M,omitempty,omitzero"`
+}
+
+// The trade stream of a single symbol.
+type tradeStream struct {
+	inst   exchanges.Instrument
+	symbol string // As used by Binance, e.g. `BTCUSDT`.
+	stream string // Name of the stream, e.g. `btcusdt@trade`.
+
+	// Either `trade` or `aggTrade`. IDs of trades are only unique within a
+	// kind.
+	kind string
+}
+
+// Returns nil if trades are not collected for the given symbol.
+func newTradeStream(cfg config.BinanceSymbol) (*tradeStream, error) {
+	if cfg.Trades == "" {
+		return nil, nil
+	}
+	if cfg.Trades != "trade" && cfg.Trades != "aggTrade" {
+		return nil, eris.Errorf("unsupported trade stream for %s: %s", cfg.Symbol, cfg.Trades)
+	}
+
+	inst, symbol, err := parseSymbol(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tradeStream{
+		inst:   inst,
+		symbol: symbol,
+		stream: strings.ToLower(symbol) + "@" + cfg.Trades,
+		kind:   cfg.Trades,
+	}, nil
+}
+
+// Converts an event of the stream into a row of the trades table.
+func (t *tradeStream) parse(data json.RawMessage) (querier.StageTradesParams, error) {
+	event := tradeEvent{}
+	err := json.Unmarshal(data, &event)
+	if err != nil {
+		return querier.StageTradesParams{}, eris.Wrapf(err, "failed to unmarshal trade of %s", t.symbol)
+	}
+
+	trade := querier.StageTradesParams{
+		Exchange:  "binance",
+		Pair:      t.inst.String(),
+		Stream:    t.kind,
+		TradeID:   event.TradeID,
+		TakerSide: "buy",
+		EventTime: time.UnixMilli(event.ETime),
+		TradeTime: time.UnixMilli(event.TTime),
+	}
+
+	if t.kind == "aggTrade" {
+		trade.TradeID = event.AggID
+	}
+	if event.BuyerMaker {
+		trade.TakerSide = "sell"
+	}
+
+	trade.Price, err = database.ParseNumeric(event.Price)
+	if err != nil {
+		return querier.StageTradesParams{}, eris.Wrapf(err, "invalid price of %s trade %d", t.symbol, trade.TradeID)
+	}
+	trade.Quantity, err = database.ParseNumeric(event.Quantity)
+	if err != nil {
+		return querier.StageTradesParams{}, eris.Wrapf(err, "invalid quantity of %s trade %d", t.symbol, trade.TradeID)
+	}
+
+	return trade, nil
+}
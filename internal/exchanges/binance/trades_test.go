@@ -0,0 +1,42 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/config"
+)
+
+// Ensures that the deprecated `M` flag does not overwrite the maker flag `m`.
+func TestParseTrade(t *testing.T) {
+	t.Parallel()
+
+	stream, err := newTradeStream(config.BinanceSymbol{Symbol: "BTCUSDT", Pair: "btc-usdt", Trades: "trade"})
+	require.NoError(t, err)
+	require.Equal(t, "btcusdt@trade", stream.stream)
+
+	for _, tc := range []struct {
+		data string
+		side string
+	}{
+		{`{"e":"trade","E":2,"s":"BTCUSDT","t":12,"p":"0.001","q":"100","T":1,"m":true,"M":true}`, "sell"},
+		{`{"e":"trade","E":2,"s":"BTCUSDT","t":12,"p":"0.001","q":"100","T":1,"m":false,"M":true}`, "buy"},
+	} {
+		trade, err := stream.parse([]byte(tc.data))
+		require.NoError(t, err)
+		require.Equal(t, tc.side, trade.TakerSide)
+		require.Equal(t, int64(12), trade.TradeID)
+		require.Equal(t, int64(1), trade.TradeTime.UnixMilli())
+		require.Equal(t, int64(2), trade.EventTime.UnixMilli())
+		require.Equal(t, "trade", trade.Stream)
+	}
+
+	// Aggregated trades are numbered separately.
+	stream, err = newTradeStream(config.BinanceSymbol{Symbol: "BTCUSDT", Pair: "btc-usdt", Trades: "aggTrade"})
+	require.NoError(t, err)
+	trade, err := stream.parse([]byte(`{"e":"aggTrade","E":2,"s":"BTCUSDT","a":7,"p":"0.001","q":"100","f":10,"l":12,"T":1,"m":true,"M":true}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), trade.TradeID)
+	require.Equal(t, "aggTrade", trade.Stream)
+}
@@ -0,0 +1,106 @@
+package exchanges
+
+import (
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/metrics"
+	"freyr/internal/order"
+)
+
+// An order book maintained from an exchange's feed. It is available via the
+// order book registry while it is complete and reports the order book
+// metrics.
+type LiveBook struct {
+	venue string
+	inst  Instrument
+
+	precision order.Precision
+	book      *order.Book
+
+	// True from the first full book until the next resync.
+	complete bool
+}
+
+func NewLiveBook(venue string, inst Instrument, precision order.Precision, granularity string) (*LiveBook, error) {
+	gran, err := precision.ParsePrice(granularity)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid granularity for %s", inst)
+	}
+
+	return &LiveBook{
+		venue:     venue,
+		inst:      inst,
+		precision: precision,
+		book:      order.NewBook(precision, gran, config.C.OrderBook.CacheWindow),
+	}, nil
+}
+
+func (b *LiveBook) Instrument() Instrument     { return b.inst }
+func (b *LiveBook) Precision() order.Precision { return b.precision }
+func (b *LiveBook) Book() *order.Book          { return b.book }
+func (b *LiveBook) Complete() bool             { return b.complete }
+
+// Replaces the book with a full one received from the exchange. The book is
+// complete and registered afterwards.
+func (b *LiveBook) Replace(asks, bids []order.Level, updateID int64) {
+	b.load(asks, bids, updateID)
+	b.publish()
+}
+
+// Replaces the book with a full one without registering it yet.
+func (b *LiveBook) load(asks, bids []order.Level, updateID int64) {
+	if b.complete {
+		b.Reset()
+	}
+	b.book.Update(asks, bids, updateID, -1)
+}
+
+// Replaces the book with the given one, e.g. stored by a previous run,
+// without registering it yet.
+func (b *LiveBook) restore(book *order.Book) {
+	b.Reset()
+	b.book = book
+}
+
+// Marks the book as complete and registers it.
+func (b *LiveBook) publish() {
+	b.complete = true
+	order.Register(b.venue, b.inst.String(), b.book)
+}
+
+// Applies an update received from the exchange.
+func (b *LiveBook) Apply(asks, bids []order.Level, updateID, timestamp int64) {
+	metrics.OrderBookUpdates.
+		WithLabelValues(b.venue, b.inst.String()).
+		Add(float64(len(asks) + len(bids)))
+
+	b.book.Update(asks, bids, updateID, timestamp)
+}
+
+// Returns true if the best bid is not below the best ask, which means that
+// the book no longer matches the exchange's.
+func (b *LiveBook) Crossed() bool {
+	view := b.book.Snapshot()
+	bestAsk, okAsk := view.BestAsk()
+	bestBid, okBid := view.BestBid()
+	return okAsk && okBid && bestBid.Price >= bestAsk.Price
+}
+
+// Discards the book until the next full book.
+func (b *LiveBook) Resync(reason string) {
+	metrics.ObserveOrderBookResync(b.venue, b.inst.String(), reason)
+	b.Reset()
+}
+
+// Discards the book without counting it as resync, e.g. on start.
+func (b *LiveBook) Reset() {
+	order.Unregister(b.venue, b.inst.String(), b.book)
+	b.book.Reset()
+	b.complete = false
+}
+
+// Removes the book from the registry.
+func (b *LiveBook) Close() {
+	order.Unregister(b.venue, b.inst.String(), b.book)
+}
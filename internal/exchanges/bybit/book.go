@@ -0,0 +1,97 @@
+package bybit
+
+import (
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+	"freyr/internal/order"
+)
+
+// The data of a message of an orderbook topic.
+type bookData struct {
+	Symbol string     `json:"s"`
+	Bids   [][]string `json:"b"` // [price, size]
+	Asks   [][]string `json:"a"`
+
+	UpdateID int64 `json:"u"`
+	Seq      int64 `json:"seq"` // cross sequence
+}
+
+// The order book of a single symbol built from an orderbook topic.
+type bybitBook struct {
+	symbol string // As used by Bybit, e.g. `BTCUSDT`.
+	topic  string // e.g. `orderbook.50.BTCUSDT`
+
+	sync *exchanges.BookSync
+}
+
+func newBybitBook(cfg config.BybitBook, depth int) (*bybitBook, error) {
+	inst, err := exchanges.ParseInstrument(cfg.Pair)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid pair of '%s'", cfg.Symbol)
+	}
+
+	symbol := cfg.Symbol
+	if symbol == "" {
+		symbol = inst.Join("")
+	}
+
+	live, err := exchanges.NewLiveBook("bybit", inst, cfg.Precision, cfg.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bybitBook{
+		symbol: symbol,
+		topic:  topic(depth, symbol),
+		sync:   exchanges.NewBookSync(live),
+	}, nil
+}
+
+// Replaces the book with the given snapshot. The book is complete afterwards.
+func (b *bybitBook) handleSnapshot(data *bookData) error {
+	asks, bids, err := b.parseLevels(data)
+	if err != nil {
+		return err
+	}
+
+	// Always succeeds since updates are not buffered.
+	b.sync.Snapshot(data.UpdateID, asks, bids)
+	return nil
+}
+
+// Applies a delta. Deltas before the first snapshot are ignored.
+func (b *bybitBook) handleDelta(data *bookData, timestamp int64) error {
+	if !b.sync.Live().Complete() {
+		return nil
+	}
+
+	asks, bids, err := b.parseLevels(data)
+	if err != nil {
+		return err
+	}
+
+	// Each delta increments the update ID by one.
+	return b.sync.Update(exchanges.BookUpdate{
+		Delta:     exchanges.RangeDelta(data.UpdateID, data.UpdateID),
+		Asks:      asks,
+		Bids:      bids,
+		Timestamp: timestamp,
+	})
+}
+
+func (b *bybitBook) parseLevels(data *bookData) (asks, bids []order.Level, err error) {
+	precision := b.sync.Live().Precision()
+
+	asks, err = precision.ParseLevels(data.Asks)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "invalid asks of %s", b.symbol)
+	}
+	bids, err = precision.ParseLevels(data.Bids)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "invalid bids of %s", b.symbol)
+	}
+
+	return asks, bids, nil
+}
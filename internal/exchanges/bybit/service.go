@@ -0,0 +1,176 @@
+package bybit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+)
+
+const (
+	// Bybit's websocket address for public spot market data.
+	FeedURL = "wss://stream.bybit.com/v5/public/spot"
+
+	// Bybit recommends a ping every 20s to keep the connection alive.
+	pingInterval = 20 * time.Second
+)
+
+// Depths of the orderbook topics supported for spot symbols.
+var depths = []int{1, 50, 200, 1000}
+
+// Returns the name of the orderbook topic of the given symbol.
+func topic(depth int, symbol string) string {
+	return "orderbook." + strconv.Itoa(depth) + "." + symbol
+}
+
+// A message received from Bybit: either data of a topic or a response to a
+// request.
+type message struct {
+	Topic string    `json:"topic"`
+	Type  string    `json:"type"`
+	TS    int64     `json:"ts"` // in ms
+	Data  *bookData `json:"data"`
+
+	// Responses.
+	Op      string `json:"op"`
+	Success bool   `json:"success"`
+	RetMsg  string `json:"ret_msg"`
+}
+
+// Maintains order books via the orderbook topics of Bybit's websocket API.
+type Bybit struct {
+	socket exchanges.Socket
+
+	// One order book per configured symbol.
+	books []*bybitBook
+}
+
+func (s *Bybit) Name() string { return "Bybit Websocket" }
+
+func (s *Bybit) Init(_ context.Context) error {
+	s.socket = exchanges.Socket{
+		Name:         "Bybit",
+		URL:          FeedURL,
+		Ping:         []byte(`{"op":"ping"}`),
+		PingInterval: pingInterval,
+	}
+
+	depth := config.C.Bybit.Depth
+	if !slices.Contains(depths, depth) {
+		return eris.Errorf("unsupported depth: %d", depth)
+	}
+
+	s.books = make([]*bybitBook, 0, len(config.C.Bybit.Books))
+	for _, bookCfg := range config.C.Bybit.Books {
+		book, err := newBybitBook(bookCfg, depth)
+		if err != nil {
+			return eris.Wrap(err, "invalid order book configuration")
+		}
+		s.books = append(s.books, book)
+	}
+
+	return nil
+}
+
+func (s *Bybit) Run(ctx context.Context) error {
+	defer func() {
+		for _, book := range s.books {
+			book.sync.Live().Close()
+		}
+	}()
+
+	if len(s.books) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	topics := make([]string, len(s.books))
+	byTopic := make(map[string]*bybitBook, len(s.books))
+	for i, book := range s.books {
+		topics[i] = book.topic
+		byTopic[book.topic] = book
+	}
+
+	return s.socket.Run(
+		ctx,
+		func() error { return s.sendRequest("subscribe", topics...) },
+		func(msg []byte) error { return s.handleMessage(byTopic, msg) },
+		func(reason string) {
+			for _, book := range s.books {
+				book.sync.Resync(reason)
+			}
+		},
+	)
+}
+
+// Passes the data of the orderbook topics on to the books. Books which fail
+// to process it are rebuilt.
+func (s *Bybit) handleMessage(byTopic map[string]*bybitBook, rawMsg []byte) error {
+	msg := &message{}
+	err := json.Unmarshal(rawMsg, msg)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal message")
+	}
+
+	if msg.Op != "" {
+		if !msg.Success {
+			return eris.Errorf("%s request failed: %s", msg.Op, msg.RetMsg)
+		}
+		return nil
+	}
+
+	book, ok := byTopic[msg.Topic]
+	if !ok || msg.Data == nil {
+		return nil
+	}
+
+	// An update ID of 1 indicates that Bybit restarted its service; the
+	// message contains a full book then.
+	if msg.Type == "snapshot" || msg.Data.UpdateID == 1 {
+		err = book.handleSnapshot(msg.Data)
+	} else {
+		err = book.handleDelta(msg.Data, msg.TS)
+	}
+	if err != nil {
+		return s.resubscribe(book, err)
+	}
+
+	return nil
+}
+
+// Rebuilds the given book after it failed to process a message by
+// subscribing again, which provides a new snapshot.
+func (s *Bybit) resubscribe(book *bybitBook, cause error) error {
+	reason := "invalid_data"
+	if errors.Is(cause, exchanges.ErrSequenceGap) {
+		reason = "sequence_gap"
+	}
+
+	book.sync.Resync(reason)
+
+	err := s.sendRequest("unsubscribe", book.topic)
+	if err == nil {
+		err = s.sendRequest("subscribe", book.topic)
+	}
+	return eris.Wrapf(err, "failed to rebuild order book for %s (%s)", book.topic, eris.ToString(cause, false))
+}
+
+// Subscribes to or unsubscribes from the given topics.
+func (s *Bybit) sendRequest(op string, topics ...string) error {
+	err := s.socket.WriteJSON(map[string]any{
+		"op":   op,
+		"args": topics,
+	})
+	return eris.Wrapf(err, "failed to send %s request", op)
+}
+
+func (s *Bybit) Stop() error {
+	return s.socket.Close()
+}
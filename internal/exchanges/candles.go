@@ -0,0 +1,94 @@
+package exchanges
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/metrics"
+)
+
+// Stores the given candles of a single instrument and interval and updates
+// the candle metrics.
+func StoreCandles(ctx context.Context, venue string, inst Instrument, intervalSeconds int32, candles []querier.StageCandlesParams) error {
+	res, err := database.UpsertCandles(ctx, candles)
+	if err != nil {
+		return err
+	}
+
+	interval := strconv.Itoa(int(intervalSeconds))
+	metrics.ObserveCandlesStored(venue, inst.String(), interval, res.Inserted, res.Updated, res.Unchanged)
+
+	if len(candles) == 0 {
+		return nil
+	}
+
+	latest := time.Time{}
+	for _, candle := range candles {
+		if latest.Before(candle.Start) {
+			latest = candle.Start
+		}
+	}
+	metrics.LatestCandleCollected.
+		WithLabelValues(venue, inst.String(), interval).
+		Set(float64(latest.Unix()))
+
+	return nil
+}
+
+// Downloads and stores all complete candles which start between `start`
+// (inclusive) and `end` (exclusive). Returns the start of the latest stored
+// candle, or the zero time if none was stored.
+func CollectCandles(
+	ctx context.Context,
+	src CandleHistory,
+	inst Instrument,
+	intervalSeconds int32,
+	start, end time.Time,
+) (time.Time, error) {
+	width := time.Duration(intervalSeconds) * time.Second
+	latest := time.Time{}
+
+	for start.Before(end) {
+		candles, err := src.DownloadCandles(ctx, inst, intervalSeconds, start)
+		if err != nil {
+			return latest, err
+		}
+
+		// Exchanges return either the next candles after `start` or those of
+		// a fixed window. Continue after whichever ends later.
+		next := start.Add(time.Duration(src.CandleLimit()) * width)
+
+		now := time.Now()
+		candles = slices.DeleteFunc(candles, func(candle querier.StageCandlesParams) bool {
+			return candle.Start.Before(start) || !candle.Start.Before(end) || candle.Start.Add(width).After(now)
+		})
+		slices.SortFunc(candles, func(a, b querier.StageCandlesParams) int {
+			return a.Start.Compare(b.Start)
+		})
+
+		if len(candles) > 0 {
+			err = StoreCandles(ctx, src.Venue(), inst, intervalSeconds, candles)
+			if err != nil {
+				return latest, err
+			}
+
+			latest = candles[len(candles)-1].Start
+			next = maxTime(next, latest.Add(width))
+		}
+
+		start = next
+	}
+
+	return latest, nil
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,93 @@
+package coinbase
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+	"freyr/internal/metrics"
+)
+
+const (
+	// How often stored candles are scanned for gaps.
+	GapScanInterval = time.Hour
+
+	// The maximum number of gaps repaired per scan.
+	MaxGapsPerScan = 100
+)
+
+// Downloads candles missing between stored ones again. Ranges for which
+// Coinbase has no candles (e.g. since there were no trades) are recorded so
+// that they are not downloaded again.
+func (svc *Coinbase) backfillGaps(ctx context.Context, stream candleStream) error {
+	gaps, err := database.FindCandleGaps(ctx, "coinbase", stream.inst.String(), int32(stream.granularity), MaxGapsPerScan)
+	if err != nil {
+		return err
+	}
+
+	metrics.CandleGaps.
+		WithLabelValues("coinbase", stream.inst.String(), stream.interval()).
+		Set(float64(len(gaps)))
+
+	for _, gap := range gaps {
+		last := gap.GapEnd.Unix()
+
+		for start := gap.GapStart.Unix(); start <= last; start += stream.granularity * MaxPayload {
+			end := min(start+stream.granularity*(MaxPayload-1), last)
+
+			candles, err := svc.downloadCandles(ctx, stream.inst, start, stream.granularity)
+			if err != nil {
+				return err
+			}
+
+			candles = slices.DeleteFunc(candles, func(candle querier.StageCandlesParams) bool {
+				ts := candle.Start.Unix()
+				return ts < start || ts > end
+			})
+
+			err = exchanges.StoreCandles(ctx, "coinbase", stream.inst, int32(stream.granularity), candles)
+			if err != nil {
+				return err
+			}
+
+			err = recordEmptyRanges(ctx, stream, start, end, candles)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Records all ranges between `start` and `end` (both inclusive) which are not
+// covered by the given candles.
+func recordEmptyRanges(ctx context.Context, stream candleStream, start, end int64, candles []querier.StageCandlesParams) error {
+	present := make(map[int64]bool, len(candles))
+	for _, candle := range candles {
+		present[candle.Start.Unix()] = true
+	}
+
+	gapStart := int64(-1)
+	for ts := start; ts <= end+stream.granularity; ts += stream.granularity {
+		missing := ts <= end && !present[ts]
+
+		if missing && gapStart < 0 {
+			gapStart = ts
+		} else if !missing && gapStart >= 0 {
+			err := database.RecordCandleGap(
+				ctx, "coinbase", stream.inst.String(), int32(stream.granularity),
+				time.Unix(gapStart, 0), time.Unix(ts-stream.granularity, 0),
+			)
+			if err != nil {
+				return err
+			}
+			gapStart = -1
+		}
+	}
+
+	return nil
+}
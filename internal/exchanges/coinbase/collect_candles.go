@@ -3,26 +3,25 @@ package coinbase
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/risingwavelabs/eris"
 
 	"freyr/internal/database"
 	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
 	"freyr/internal/metrics"
 )
 
 const (
-	// The timestamp of the oldest candle we care about.
+	// The timestamp of the oldest candle we care about if a product has no
+	// configured start.
 	MinTimestamp int64 = 1577836800 // 2020-01-01 00:00:00 UTC
 
-	// The width of a candle in seconds.
-	Granularity int64 = 60
-
 	// The maximum number of candles in a single request.
 	MaxPayload = 300
 
@@ -30,111 +29,93 @@ const (
 	Url string = "https://api.exchange.coinbase.com/products/%s/candles/?granularity=%d&start=%d&end=%d"
 )
 
-// Collects the candles for a given pair since the latest candle.
-func (svc *Coinbase) collectRecentCandles(ctx context.Context, pair string) error {
-	start, err := svc.startTimestamp(ctx, pair)
-	if err != nil {
-		return err
-	}
+// A series of candles of one product and granularity.
+type candleStream struct {
+	inst        exchanges.Instrument
+	granularity int64 // width of a candle in seconds
+	start       int64 // timestamp of the oldest candle we care about
+}
 
-	now := time.Now().Unix()
-	now -= now % Granularity
+// The granularity as used in metrics.
+func (s candleStream) interval() string {
+	return strconv.FormatInt(s.granularity, 10)
+}
 
-	for ; start <= now; start += Granularity * MaxPayload {
-		candles, err := svc.downloadCandles(ctx, pair, start, Granularity)
-		if err != nil {
-			return err
-		}
+func (Coinbase) Venue() string { return "coinbase" }
 
-		err = database.InsertCandles(ctx, candles)
-		if err != nil {
-			return err
-		}
+// Coinbase names products like `BTC-USD`.
+func (Coinbase) Symbol(inst exchanges.Instrument) string { return inst.Join("-") }
 
-		if len(candles) == 0 {
-			continue
-		}
+func (*Coinbase) CandleLimit() int { return MaxPayload }
 
-		maxTS := time.Time{}
-		for _, candle := range candles {
-			if maxTS.Before(candle.Start) {
-				maxTS = candle.Start
-			}
-		}
-		metrics.LatestCandleCollected.
-			WithLabelValues("coinbase", pair).
-			Set(float64(maxTS.Unix()))
+// Downloads the candles of the `MaxPayload` intervals starting at `start`.
+func (svc *Coinbase) DownloadCandles(ctx context.Context, inst exchanges.Instrument, intervalSeconds int32, start time.Time) ([]querier.StageCandlesParams, error) {
+	return svc.downloadCandles(ctx, inst, start.Unix(), int64(intervalSeconds))
+}
+
+// Collects the candles of a given stream since the latest candle.
+func (svc *Coinbase) collectRecentCandles(ctx context.Context, stream candleStream) error {
+	start, err := svc.startTimestamp(ctx, stream)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	_, err = exchanges.CollectCandles(ctx, svc, stream.inst, int32(stream.granularity), time.Unix(start, 0), time.Now())
+	return err
 }
 
-func (svc *Coinbase) startTimestamp(ctx context.Context, pair string) (int64, error) {
-	lastOld, err := database.GetLatestCandle(ctx, pair)
+func (svc *Coinbase) startTimestamp(ctx context.Context, stream candleStream) (int64, error) {
+	lastOld, err := database.GetLatestCandle(ctx, "coinbase", stream.inst.String(), int32(stream.granularity))
 	if err != nil {
 		return 0, err
 	}
 
 	if lastOld != (time.Time{}) {
-		return lastOld.Unix() + Granularity, nil
+		return lastOld.Unix() + stream.granularity, nil
 	}
 
 	//
-	// No timestamp known: Find first available day.
-
-	const GranDay int64 = 24 * 60 * 60
-	today := time.Now().Unix()
-	today -= today % GranDay
-
-	var candles []querier.InsertCandlesParams
+	// No candles stored: Start with the first candle Coinbase offers.
 
-	for start := MinTimestamp; start <= today && len(candles) == 0; start += GranDay * MaxPayload {
-		candles, err = svc.downloadCandles(ctx, pair, MinTimestamp, GranDay)
-		if err != nil {
-			return 0, err
-		}
+	listing, found, err := svc.listingTimestamp(ctx, stream.inst)
+	if err != nil {
+		return 0, err
 	}
-
-	minTS := today
-	for _, candle := range candles {
-		minTS = min(minTS, candle.Start.Unix())
+	if !found {
+		// No candles yet: Collect from now on.
+		listing = time.Now().Unix()
 	}
 
-	return minTS, nil
+	start := max(listing, stream.start)
+	return start - start%stream.granularity, nil
 }
 
-func (svc *Coinbase) downloadCandles(ctx context.Context, pair string, start, granularity int64) ([]querier.InsertCandlesParams, error) {
+func (svc *Coinbase) downloadCandles(ctx context.Context, inst exchanges.Instrument, start, granularity int64) ([]querier.StageCandlesParams, error) {
 	// Compute/normalise timestamps.
-	start -= start % Granularity
-	end := start + Granularity*(MaxPayload-1) // `end` is inclusive.
+	start -= start % granularity
+	end := start + granularity*(MaxPayload-1) // `end` is inclusive.
 
 	//
 	// Build and execute request.
 
-	req, err := buildRequest(ctx, pair, start, end)
+	req, err := buildRequest(ctx, svc.Symbol(inst), granularity, start, end)
 	if err != nil {
 		return nil, err
 	}
 
 	metrics.LatestCandleQueried.
-		WithLabelValues("coinbase", pair).
+		WithLabelValues("coinbase", inst.String(), strconv.FormatInt(granularity, 10)).
 		Set(float64(end))
 
-	res, err := svc.httpClient.Do(req)
+	body, err := svc.execute(req)
 	if err != nil {
-		return nil, err
+		return nil, eris.Wrapf(err, "failed to download candles of '%s'", inst)
 	}
-	defer func() { _ = res.Body.Close() }()
 
 	//
 	// Process response.
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	candles, err := parseCandles(pair, body)
+	candles, err := parseCandles(inst.String(), granularity, body)
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +123,8 @@ func (svc *Coinbase) downloadCandles(ctx context.Context, pair string, start, gr
 	return candles, nil
 }
 
-func buildRequest(ctx context.Context, pair string, start, end int64) (*http.Request, error) {
-	url := fmt.Sprintf(Url, pair, 60, start, end)
+func buildRequest(ctx context.Context, product string, granularity, start, end int64) (*http.Request, error) {
+	url := fmt.Sprintf(Url, product, granularity, start, end)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -154,8 +135,8 @@ func buildRequest(ctx context.Context, pair string, start, end int64) (*http.Req
 	return req, nil
 }
 
-func parseCandles(pair string, body []byte) ([]querier.InsertCandlesParams, error) {
-	candles := []querier.InsertCandlesParams{}
+func parseCandles(pair string, granularity int64, body []byte) ([]querier.StageCandlesParams, error) {
+	candles := []querier.StageCandlesParams{}
 
 	depth := 0
 	for i := 0; i < len(body); i++ {
@@ -199,17 +180,19 @@ func parseCandles(pair string, body []byte) ([]querier.InsertCandlesParams, erro
 				return nil, eris.Wrapf(err, "failed to parse string as timestamp: '%s'", fields[0])
 			}
 
-			candle := querier.InsertCandlesParams{
-				Pair:  pair,
-				Start: time.Unix(timestamp, 0),
+			candle := querier.StageCandlesParams{
+				Exchange:        "coinbase",
+				Pair:            pair,
+				IntervalSeconds: int32(granularity),
+				Start:           time.Unix(timestamp, 0),
 			}
 
-			for i, dst := range []*float64{
+			for i, dst := range []*pgtype.Numeric{
 				&candle.PriceLow, &candle.PriceHigh,
 				&candle.PriceOpen, &candle.PriceClose,
 				&candle.Volume,
 			} {
-				*dst, err = strconv.ParseFloat(fields[i+1], 64)
+				*dst, err = database.ParseNumeric(fields[i+1])
 				if err != nil {
 					return nil, eris.Wrapf(err, "failed to parse string as price/volume: '%s'", fields[i+1])
 				}
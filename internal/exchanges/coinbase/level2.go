@@ -0,0 +1,142 @@
+package coinbase
+
+import (
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+)
+
+// Signals that the book no longer matches Coinbase's. The level2 channels
+// have no sequence numbers; missed updates show as a crossed book.
+var errCrossedBook = eris.New("crossed order book")
+
+// A message of Coinbase's websocket feed.
+type feedMessage struct {
+	Type      string `json:"type"`
+	ProductID string `json:"product_id"`
+	Time      string `json:"time"`
+
+	// Snapshots: levels as [price, size].
+	Asks [][]string `json:"asks"`
+	Bids [][]string `json:"bids"`
+
+	// Updates: changes as [side, price, size].
+	Changes [][]string `json:"changes"`
+
+	// Errors.
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+// Returns the time of the message in ms, or the current time if it has none.
+func (msg *feedMessage) timestamp() int64 {
+	ts, err := time.Parse(time.RFC3339Nano, msg.Time)
+	if err != nil {
+		return time.Now().UnixMilli()
+	}
+	return ts.UnixMilli()
+}
+
+// The order book of a single product built from the level2_batch channel.
+type level2Book struct {
+	product string // As used by Coinbase, e.g. `BTC-USD`.
+
+	live *exchanges.LiveBook
+
+	// Coinbase does not number updates; we count them instead.
+	updateID int64
+}
+
+func newLevel2Book(cfg config.CoinbaseBook) (*level2Book, error) {
+	pair := cfg.Pair
+	if pair == "" {
+		pair = cfg.Product
+	}
+
+	inst, err := exchanges.ParseInstrument(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	product := cfg.Product
+	if product == "" {
+		product = Coinbase{}.Symbol(inst)
+	}
+
+	live, err := exchanges.NewLiveBook("coinbase", inst, cfg.Precision, cfg.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &level2Book{product: product, live: live}, nil
+}
+
+// Replaces the book with the given snapshot. The book is complete afterwards.
+func (l *level2Book) handleSnapshot(msg *feedMessage) error {
+	precision := l.live.Precision()
+
+	asks, err := precision.ParseLevels(msg.Asks)
+	if err != nil {
+		return eris.Wrapf(err, "invalid asks of %s", l.product)
+	}
+	bids, err := precision.ParseLevels(msg.Bids)
+	if err != nil {
+		return eris.Wrapf(err, "invalid bids of %s", l.product)
+	}
+
+	l.updateID++
+	l.live.Replace(asks, bids, l.updateID)
+	return nil
+}
+
+// Applies an update. Updates before the first snapshot are ignored.
+func (l *level2Book) handleUpdate(msg *feedMessage) error {
+	if !l.live.Complete() {
+		return nil
+	}
+
+	rawAsks := make([][]string, 0, len(msg.Changes))
+	rawBids := make([][]string, 0, len(msg.Changes))
+
+	for _, change := range msg.Changes {
+		if len(change) != 3 {
+			return eris.Errorf("invalid change of %s: %v", l.product, change)
+		}
+
+		switch change[0] {
+		case "sell":
+			rawAsks = append(rawAsks, change[1:])
+		case "buy":
+			rawBids = append(rawBids, change[1:])
+		default:
+			return eris.Errorf("invalid side of %s: %s", l.product, change[0])
+		}
+	}
+
+	precision := l.live.Precision()
+
+	asks, err := precision.ParseLevels(rawAsks)
+	if err != nil {
+		return eris.Wrapf(err, "invalid asks of %s", l.product)
+	}
+	bids, err := precision.ParseLevels(rawBids)
+	if err != nil {
+		return eris.Wrapf(err, "invalid bids of %s", l.product)
+	}
+
+	l.updateID++
+	l.live.Apply(asks, bids, l.updateID, msg.timestamp())
+
+	if l.live.Crossed() {
+		view := l.live.Book().Snapshot()
+		bestAsk, _ := view.BestAsk()
+		bestBid, _ := view.BestBid()
+		return eris.Wrapf(errCrossedBook, "%s: bid %s >= ask %s",
+			l.product, precision.FormatPrice(bestBid.Price), precision.FormatPrice(bestAsk.Price))
+	}
+
+	return nil
+}
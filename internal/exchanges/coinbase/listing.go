@@ -0,0 +1,135 @@
+package coinbase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"freyr/internal/database"
+	"freyr/internal/exchanges"
+)
+
+// How long a product without candles is not searched again.
+const UnlistedRecheckInterval = time.Hour
+
+// Returns the timestamp of the first candle Coinbase offers for the given
+// product (but not before `MinTimestamp`). It is discovered once and stored
+// afterwards. Returns false if the product has no candles yet.
+func (svc *Coinbase) listingTimestamp(ctx context.Context, inst exchanges.Instrument) (int64, bool, error) {
+	listing, err := database.GetListing(ctx, "coinbase", inst.String())
+	if err != nil {
+		return 0, false, err
+	}
+	if !listing.IsZero() {
+		return listing.Unix(), true, nil
+	}
+
+	first, found, err := svc.searchListing(ctx, inst)
+	if err != nil || !found {
+		return 0, false, err
+	}
+
+	fmt.Printf("First Coinbase candle of '%s' is at %s.\n", inst, time.Unix(first, 0).UTC())
+
+	err = database.SaveListing(ctx, "coinbase", inst.String(), time.Unix(first, 0))
+	if err != nil {
+		return 0, false, err
+	}
+
+	return first, true, nil
+}
+
+// Products which had no candles, by the time they were searched. Safe for
+// concurrent use.
+type unlistedProducts struct {
+	lock     sync.Mutex
+	searched map[exchanges.Instrument]time.Time
+}
+
+// Returns true if the product had no candles within the last
+// `UnlistedRecheckInterval`.
+func (u *unlistedProducts) recent(inst exchanges.Instrument) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	searched, ok := u.searched[inst]
+	return ok && time.Since(searched) < UnlistedRecheckInterval
+}
+
+func (u *unlistedProducts) update(inst exchanges.Instrument, found bool) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if found {
+		delete(u.searched, inst)
+	} else {
+		u.searched[inst] = time.Now()
+	}
+}
+
+// Finds the first candle of a product unless it had none when it was last
+// searched within `UnlistedRecheckInterval`.
+func (svc *Coinbase) searchListing(ctx context.Context, inst exchanges.Instrument) (int64, bool, error) {
+	if svc.unlisted.recent(inst) {
+		return 0, false, nil
+	}
+
+	first, found, err := svc.findFirstCandle(ctx, inst)
+	if err != nil {
+		return 0, false, err
+	}
+
+	svc.unlisted.update(inst, found)
+	return first, found, nil
+}
+
+// Finds the first candle of a product: First, the first daily candle is
+// searched in windows of `MaxPayload` days. Then, the first minute candle of
+// that day is searched in windows of `MaxPayload` minutes.
+func (svc *Coinbase) findFirstCandle(ctx context.Context, inst exchanges.Instrument) (int64, bool, error) {
+	const (
+		granDay    int64 = 24 * 60 * 60
+		granMinute int64 = 60
+	)
+
+	firstDay, found, err := svc.findFirstCandleIn(ctx, inst, MinTimestamp, time.Now().Unix(), granDay)
+	if err != nil || !found {
+		return 0, false, err
+	}
+
+	firstMinute, found, err := svc.findFirstCandleIn(ctx, inst, firstDay, firstDay+granDay, granMinute)
+	if err != nil {
+		return 0, false, err
+	}
+	if !found {
+		// Should not happen; fall back to the start of the day.
+		return firstDay, true, nil
+	}
+
+	return firstMinute, true, nil
+}
+
+// Returns the earliest candle of the given granularity between `start` and
+// `end` (exclusive).
+func (svc *Coinbase) findFirstCandleIn(ctx context.Context, inst exchanges.Instrument, start, end, granularity int64) (int64, bool, error) {
+	for ; start < end; start += granularity * MaxPayload {
+		candles, err := svc.downloadCandles(ctx, inst, start, granularity)
+		if err != nil {
+			return 0, false, err
+		}
+
+		first := int64(-1)
+		for _, candle := range candles {
+			ts := candle.Start.Unix()
+			if ts < end && (first < 0 || ts < first) {
+				first = ts
+			}
+		}
+		if first >= 0 {
+			return first, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
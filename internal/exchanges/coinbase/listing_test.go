@@ -0,0 +1,53 @@
+package coinbase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/exchanges"
+	"freyr/internal/utils"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Ensures that a product without candles is not searched again right away.
+func TestSearchListingUnlisted(t *testing.T) {
+	t.Parallel()
+
+	requests := atomic.Int64{}
+	svc := &Coinbase{
+		httpClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("[]")), Request: req}, nil
+		})},
+		limiter:  utils.NewRateLimiter(1000, 1000),
+		unlisted: &unlistedProducts{searched: map[exchanges.Instrument]time.Time{}},
+	}
+	inst := exchanges.Instrument{Base: "new", Quote: "usd"}
+
+	_, found, err := svc.searchListing(context.Background(), inst)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Positive(t, requests.Load())
+
+	searched := requests.Load()
+	_, found, err = svc.searchListing(context.Background(), inst)
+	require.NoError(t, err)
+	require.False(t, found)
+	require.Equal(t, searched, requests.Load())
+
+	// The product is searched again once the result expired.
+	svc.unlisted.searched[inst] = time.Now().Add(-UnlistedRecheckInterval)
+	_, _, err = svc.searchListing(context.Background(), inst)
+	require.NoError(t, err)
+	require.Equal(t, 2*searched, requests.Load())
+}
@@ -0,0 +1,140 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+)
+
+// Coinbase Exchange's websocket feed of market data.
+const FeedUrl string = "wss://ws-feed.exchange.coinbase.com"
+
+// Maintains order books via the level2_batch channel of Coinbase's websocket
+// feed.
+type OrderBooks struct {
+	socket exchanges.Socket
+
+	// One order book per configured product.
+	books []*level2Book
+}
+
+func (s *OrderBooks) Name() string { return "Coinbase Websocket" }
+
+func (s *OrderBooks) Init(_ context.Context) error {
+	s.socket = exchanges.Socket{Name: "Coinbase", URL: FeedUrl}
+
+	s.books = make([]*level2Book, 0, len(config.C.Coinbase.Books))
+	for _, bookCfg := range config.C.Coinbase.Books {
+		book, err := newLevel2Book(bookCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid order book configuration")
+		}
+		s.books = append(s.books, book)
+	}
+
+	return nil
+}
+
+func (s *OrderBooks) Run(ctx context.Context) error {
+	defer func() {
+		for _, book := range s.books {
+			book.live.Close()
+		}
+	}()
+
+	if len(s.books) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	products := make([]string, len(s.books))
+	byProduct := make(map[string]*level2Book, len(s.books))
+	for i, book := range s.books {
+		products[i] = book.product
+		byProduct[book.product] = book
+	}
+
+	return s.socket.Run(
+		ctx,
+		func() error { return s.sendMessage("subscribe", products...) },
+		func(msg []byte) error { return s.handleMessage(byProduct, msg) },
+		func(reason string) {
+			for _, book := range s.books {
+				book.live.Resync(reason)
+			}
+		},
+	)
+}
+
+// Passes snapshots and updates on to the books. Books which fail to process
+// them are rebuilt.
+func (s *OrderBooks) handleMessage(byProduct map[string]*level2Book, rawMsg []byte) error {
+	msg := &feedMessage{}
+	err := json.Unmarshal(rawMsg, msg)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal message")
+	}
+
+	switch msg.Type {
+	case "error":
+		return eris.Errorf("error from Coinbase: %s (%s)", msg.Message, msg.Reason)
+
+	case "snapshot", "l2update":
+		book, ok := byProduct[msg.ProductID]
+		if !ok {
+			return nil
+		}
+
+		if msg.Type == "snapshot" {
+			err = book.handleSnapshot(msg)
+		} else {
+			err = book.handleUpdate(msg)
+		}
+		if err != nil {
+			return s.resubscribe(book, err)
+		}
+
+	default:
+		// E.g. confirmation of subscriptions.
+	}
+
+	return nil
+}
+
+// Rebuilds the given book after it failed to process a message by
+// subscribing again, which provides a new snapshot.
+func (s *OrderBooks) resubscribe(book *level2Book, cause error) error {
+	// The channel has no sequence numbers; a crossed book is the only sign
+	// of a missed update.
+	reason := "invalid_data"
+	if errors.Is(cause, errCrossedBook) {
+		reason = "crossed_book"
+	}
+	book.live.Resync(reason)
+
+	err := s.sendMessage("unsubscribe", book.product)
+	if err == nil {
+		err = s.sendMessage("subscribe", book.product)
+	}
+	return eris.Wrapf(err, "failed to rebuild order book for %s (%s)", book.product, eris.ToString(cause, false))
+}
+
+// Subscribes to or unsubscribes from the level2_batch channel of the given
+// products.
+func (s *OrderBooks) sendMessage(msgType string, products ...string) error {
+	err := s.socket.WriteJSON(map[string]any{
+		"type":        msgType,
+		"product_ids": products,
+		"channels":    []string{"level2_batch"},
+	})
+	return eris.Wrapf(err, "failed to send %s message", msgType)
+}
+
+func (s *OrderBooks) Stop() error {
+	return s.socket.Close()
+}
@@ -0,0 +1,152 @@
+package coinbase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
+)
+
+// The API endpoint listing all products.
+const ProductsUrl string = "https://api.exchange.coinbase.com/products"
+
+// A product as listed by Coinbase.
+type product struct {
+	ID              string `json:"id"`
+	BaseCurrency    string `json:"base_currency"`
+	QuoteCurrency   string `json:"quote_currency"`
+	QuoteIncrement  string `json:"quote_increment"`
+	BaseIncrement   string `json:"base_increment"`
+	MinMarketFunds  string `json:"min_market_funds"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+}
+
+// Downloads all products offered by Coinbase into the catalogue. Returns the
+// updated catalogue.
+func (svc *Coinbase) refreshCatalogue(ctx context.Context) ([]*querier.Product, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ProductsUrl, nil)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to build HTTP request")
+	}
+
+	body, err := svc.execute(req)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to download products")
+	}
+
+	var listed []product
+	err = json.Unmarshal(body, &listed)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to unmarshal products")
+	}
+
+	products := make([]querier.UpsertProductParams, 0, len(listed))
+	for _, p := range listed {
+		row := querier.UpsertProductParams{
+			Exchange:        "coinbase",
+			Product:         strings.ToLower(p.ID),
+			BaseCurrency:    p.BaseCurrency,
+			QuoteCurrency:   p.QuoteCurrency,
+			Status:          p.Status,
+			TradingDisabled: p.TradingDisabled,
+		}
+
+		for _, field := range []struct {
+			dst   *pgtype.Numeric
+			value string
+		}{
+			{&row.TickSize, p.QuoteIncrement},
+			{&row.LotSize, p.BaseIncrement},
+		} {
+			*field.dst, err = database.ParseNumeric(field.value)
+			if err != nil {
+				return nil, eris.Wrapf(err, "invalid product '%s'", p.ID)
+			}
+		}
+
+		// Optional.
+		if p.MinMarketFunds != "" {
+			row.MinFunds, err = database.ParseNumeric(p.MinMarketFunds)
+			if err != nil {
+				return nil, eris.Wrapf(err, "invalid product '%s'", p.ID)
+			}
+		}
+
+		products = append(products, row)
+	}
+
+	delisted, err := database.UpdateProducts(ctx, "coinbase", products)
+	if err != nil {
+		return nil, err
+	}
+	if delisted > 0 {
+		fmt.Printf("%d Coinbase product(s) are no longer listed.\n", delisted)
+	}
+
+	return database.GetProducts(ctx, "coinbase")
+}
+
+// Returns the streams to collect: the configured ones and, if enabled, those
+// of all products matching the discovery filters. Delisted products are
+// skipped. Configured products missing in the catalogue are collected.
+func (svc *Coinbase) selectStreams(catalogue []*querier.Product) []candleStream {
+	byID := make(map[string]*querier.Product, len(catalogue))
+	for _, p := range catalogue {
+		byID[p.Product] = p
+	}
+
+	streams := []candleStream{}
+	for _, stream := range svc.configured {
+		if p, ok := byID[stream.inst.String()]; ok && p.DelistedAt != nil {
+			continue
+		}
+		streams = append(streams, stream)
+	}
+
+	discoverCfg := config.C.Coinbase.Discover
+	if !discoverCfg.Enabled {
+		return streams
+	}
+
+	for _, p := range catalogue {
+		if p.DelistedAt != nil {
+			continue
+		}
+		if len(discoverCfg.QuoteCurrencies) > 0 &&
+			!slices.ContainsFunc(discoverCfg.QuoteCurrencies, func(c string) bool { return strings.EqualFold(c, p.QuoteCurrency) }) {
+			continue
+		}
+		if len(discoverCfg.Statuses) > 0 && !slices.Contains(discoverCfg.Statuses, p.Status) {
+			continue
+		}
+
+		inst, err := exchanges.ParseInstrument(p.Product)
+		if err != nil {
+			continue
+		}
+
+		for _, stream := range svc.discovered {
+			stream.inst = inst
+
+			configured := slices.ContainsFunc(streams, func(s candleStream) bool {
+				return s.inst == stream.inst && s.granularity == stream.granularity
+			})
+			if !configured {
+				streams = append(streams, stream)
+			}
+		}
+	}
+
+	return streams
+}
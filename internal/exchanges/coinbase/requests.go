@@ -0,0 +1,124 @@
+package coinbase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/metrics"
+	"freyr/internal/utils"
+)
+
+const (
+	// Coinbase's public endpoints allow 10 requests per second with bursts of
+	// up to 15 requests.
+	PublicRateLimit = 10
+	PublicRateBurst = 15
+
+	// How often a request is attempted before giving up.
+	MaxAttempts = 5
+)
+
+// An unsuccessful response from Coinbase.
+type StatusError struct {
+	StatusCode int
+
+	// How long Coinbase asks to wait before retrying (0 if unknown).
+	RetryAfter time.Duration
+
+	// The beginning of the response body.
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("invalid status code %d: %s", e.StatusCode, e.Body)
+}
+
+// True if repeating the request may succeed, i.e., after exceeding the rate
+// limit or after a server error.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// Executes the given request and returns the response body. Requests obey
+// the rate limit shared by all pairs. Failures which may be temporary are
+// retried with increasing delays.
+func (svc *Coinbase) execute(req *http.Request) ([]byte, error) {
+	ctx := req.Context()
+	backoff := utils.Backoff{Min: 500 * time.Millisecond, Max: 30 * time.Second}
+
+	for attempt := 1; ; attempt++ {
+		if !svc.limiter.Wait(ctx) {
+			return nil, eris.Wrap(ctx.Err(), "request cancelled")
+		}
+
+		body, err := svc.executeOnce(req)
+		metrics.ExchangeRequests.WithLabelValues("coinbase", requestOutcome(err)).Inc()
+		if err == nil {
+			return body, nil
+		}
+
+		delay := backoff.Next()
+		statusErr := &StatusError{}
+		if errors.As(err, &statusErr) {
+			if !statusErr.Retryable() {
+				return nil, err
+			}
+			delay = max(delay, statusErr.RetryAfter)
+		}
+
+		if attempt >= MaxAttempts || ctx.Err() != nil {
+			return nil, eris.Wrapf(err, "request failed after %d attempt(s)", attempt)
+		}
+
+		if !utils.Sleep(ctx, delay) {
+			return nil, err
+		}
+	}
+}
+
+func (svc *Coinbase) executeOnce(req *http.Request) ([]byte, error) {
+	res, err := svc.httpClient.Do(req)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to execute request")
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 256))
+		return nil, &StatusError{
+			StatusCode: res.StatusCode,
+			RetryAfter: utils.RetryAfter(res),
+			Body:       string(body),
+		}
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read response")
+	}
+
+	return body, nil
+}
+
+// Classifies the result of a request for metrics.
+func requestOutcome(err error) string {
+	statusErr := &StatusError{}
+
+	switch {
+	case err == nil:
+		return "success"
+	case !errors.As(err, &statusErr):
+		return "network_error"
+	case statusErr.StatusCode == http.StatusTooManyRequests:
+		return "rate_limited"
+	case statusErr.StatusCode >= 500:
+		return "server_error"
+	default:
+		return "client_error"
+	}
+}
@@ -4,51 +4,192 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/risingwavelabs/eris"
 
+	"freyr/internal/config"
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/exchanges"
 	"freyr/internal/utils"
 )
 
-// const (
-// )
+// Widths of candles (in seconds) supported by Coinbase.
+var granularities = []int64{60, 300, 900, 3600, 21600, 86400}
 
 type Coinbase struct {
 	httpClient *http.Client
+
+	// Shared by the requests of all streams.
+	limiter *utils.RateLimiter
+
+	// One stream per configured product and granularity.
+	configured []candleStream
+
+	// One stream per granularity collected for discovered products. Their
+	// instrument is not set.
+	discovered []candleStream
+
+	// Products which had no candles when they were searched.
+	unlisted *unlistedProducts
 }
 
+var _ exchanges.CandleHistory = (*Coinbase)(nil)
+
 func (Coinbase) Name() string { return "Candle Collector" }
 func (Coinbase) Stop() error  { return nil }
 
 func (svc *Coinbase) Init(_ context.Context) error {
 	svc.httpClient = &http.Client{}
+	svc.limiter = utils.NewRateLimiter(PublicRateLimit, PublicRateBurst)
+	svc.unlisted = &unlistedProducts{searched: map[exchanges.Instrument]time.Time{}}
+
+	if config.C.Coinbase.CatalogueInterval <= 0 {
+		return eris.New("invalid catalogue interval")
+	}
+
+	svc.configured = nil
+	for _, product := range config.C.Coinbase.Products {
+		if product.Product == "" {
+			return eris.New("invalid product configuration: missing product")
+		}
+
+		inst, err := exchanges.ParseInstrument(product.Product)
+		if err != nil {
+			return eris.Wrap(err, "invalid product configuration")
+		}
+
+		streams, err := newStreams(inst, product.Granularities, product.Start)
+		if err != nil {
+			return err
+		}
+		svc.configured = append(svc.configured, streams...)
+	}
+
+	discoverCfg := config.C.Coinbase.Discover
+	if !discoverCfg.Enabled {
+		return nil
+	}
+
+	var err error
+	svc.discovered, err = newStreams(exchanges.Instrument{}, discoverCfg.Granularities, discoverCfg.Start)
+	if err != nil {
+		return eris.Wrap(err, "invalid discovery configuration")
+	}
+
 	return nil
 }
 
+func newStreams(inst exchanges.Instrument, grans []int64, start time.Time) ([]candleStream, error) {
+	startTS := MinTimestamp
+	if !start.IsZero() {
+		startTS = start.Unix()
+	}
+
+	streams := make([]candleStream, 0, len(grans))
+	for _, granularity := range grans {
+		if !slices.Contains(granularities, granularity) {
+			return nil, eris.Errorf("unsupported granularity for '%s': %d", inst, granularity)
+		}
+
+		streams = append(streams, candleStream{
+			inst:        inst,
+			granularity: granularity,
+			start:       startTS,
+		})
+	}
+
+	return streams, nil
+}
+
 func (svc *Coinbase) Run(ctx context.Context) error {
-	// TODO: Move into config.
-	pairs := []string{"btc-usd", "sol-usd", "sol-btc", "eth-usd"}
+	wg := sync.WaitGroup{}
+	running := map[candleStream]context.CancelFunc{}
 
-	ticker := time.NewTicker(time.Duration(Granularity) * time.Second)
-	for range utils.CtxChanIter(ctx, ticker.C) {
-		wg := sync.WaitGroup{}
-		wg.Add(len(pairs))
+	defer func() {
+		for _, cancel := range running {
+			cancel()
+		}
+		wg.Wait()
+	}()
 
-		for _, pair := range pairs {
-			go func(pair string) {
-				defer wg.Done()
+	ticker := time.NewTicker(config.C.Coinbase.CatalogueInterval)
+	defer ticker.Stop()
 
-				err := svc.collectRecentCandles(ctx, pair)
-				if err != nil {
-					fmt.Printf("Error when collecting '%s': %s\n", pair, eris.ToString(err, true))
-				}
-			}(pair)
+	for {
+		catalogue, err := svc.refreshCatalogue(ctx)
+		if err != nil {
+			fmt.Printf("Failed to update Coinbase products: %s\n", eris.ToString(err, true))
+
+			// Fall back to the last known catalogue.
+			catalogue, err = database.GetProducts(ctx, "coinbase")
+			if err != nil {
+				fmt.Printf("Failed to load Coinbase products: %s\n", eris.ToString(err, true))
+				catalogue = []*querier.Product{}
+			}
 		}
 
-		wg.Wait()
+		//
+		// Start new streams and stop those which are no longer selected.
+
+		selected := svc.selectStreams(catalogue)
+
+		for stream, cancel := range running {
+			if !slices.Contains(selected, stream) {
+				fmt.Printf("Stop collecting '%s' (%ds).\n", stream.inst, stream.granularity)
+				cancel()
+				delete(running, stream)
+			}
+		}
+
+		for _, stream := range selected {
+			if _, ok := running[stream]; ok {
+				continue
+			}
+
+			streamCtx, cancel := context.WithCancel(ctx)
+			running[stream] = cancel
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				svc.runStream(streamCtx, stream)
+			}()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
 	}
+}
 
-	return nil
+// Collects new candles of the given stream once per interval. Gaps are
+// repaired every `GapScanInterval`.
+func (svc *Coinbase) runStream(ctx context.Context, stream candleStream) {
+	nextGapScan := time.Now()
+
+	ticker := time.NewTicker(time.Duration(stream.granularity) * time.Second)
+	defer ticker.Stop()
+
+	for range utils.CtxChanIter(ctx, ticker.C) {
+		err := svc.collectRecentCandles(ctx, stream)
+		if err != nil {
+			fmt.Printf("Error when collecting '%s' (%ds): %s\n", stream.inst, stream.granularity, eris.ToString(err, true))
+		}
+
+		if time.Now().Before(nextGapScan) {
+			continue
+		}
+		nextGapScan = time.Now().Add(GapScanInterval)
+
+		err = svc.backfillGaps(ctx, stream)
+		if err != nil {
+			fmt.Printf("Error when backfilling gaps of '%s' (%ds): %s\n", stream.inst, stream.granularity, eris.ToString(err, true))
+		}
+	}
 }
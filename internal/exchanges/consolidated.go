@@ -0,0 +1,70 @@
+package exchanges
+
+import (
+	"maps"
+	"slices"
+
+	"freyr/internal/config"
+	"freyr/internal/order"
+)
+
+// Merges the current books of the given instrument of all venues (see
+// `BookSources()`). The second return value is false if no book is available.
+func ConsolidatedBook(inst Instrument, fees bool) (order.Consolidated, bool) {
+	sources := BookSources(inst, fees)
+	if len(sources) == 0 {
+		return order.Consolidated{}, false
+	}
+	return order.Consolidate(sources...), true
+}
+
+// Returns the current books of the given instrument of all venues, sorted by
+// quote currency and venue. Books quoted in an equivalent currency (see
+// `config.C.Consolidation.QuoteRates`) are converted into the instrument's
+// quote currency. If `fees` is true, prices are adjusted by the venues' taker
+// fees.
+func BookSources(inst Instrument, fees bool) []order.Source {
+	var sources []order.Source
+
+	rates := quoteRates(inst.Quote)
+	for _, quote := range slices.Sorted(maps.Keys(rates)) {
+		pair := Instrument{Base: inst.Base, Quote: quote}.String()
+
+		books := order.LookupPair(pair)
+		for _, venue := range slices.Sorted(maps.Keys(books)) {
+			source := order.Source{
+				Exchange: venue,
+				Pair:     pair,
+				View:     books[venue].Snapshot(),
+				Rate:     rates[quote],
+			}
+			if fees {
+				source.Fee = config.C.Consolidation.TakerFees[venue]
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// Returns the quote currencies equivalent to the given one along with their
+// rates, i.e., the price of one unit of each in the given currency. The
+// currency itself is included with a rate of 1.
+func quoteRates(quote string) map[string]float64 {
+	rates := map[string]float64{quote: 1}
+	for from, to := range config.C.Consolidation.QuoteRates {
+		for other, rate := range to {
+			if rate <= 0 {
+				continue
+			}
+			switch quote {
+			case other:
+				rates[from] = rate
+			case from:
+				rates[other] = 1 / rate
+			}
+		}
+	}
+	return rates
+}
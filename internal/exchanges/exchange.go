@@ -0,0 +1,31 @@
+package exchanges
+
+import (
+	"context"
+	"time"
+
+	"freyr/internal/database/querier"
+)
+
+// An exchange of which market data is collected. Adapters additionally
+// implement the interfaces of the data they provide.
+type Exchange interface {
+	// The name used in the database, in metrics, and in the order book
+	// registry, e.g. `binance`.
+	Venue() string
+
+	// Translates the instrument into the exchange's symbol, e.g. `BTCUSDT`.
+	Symbol(inst Instrument) string
+}
+
+// An exchange providing past candles.
+type CandleHistory interface {
+	Exchange
+
+	// The maximum number of candles returned by a single `DownloadCandles`.
+	CandleLimit() int
+
+	// Downloads up to `CandleLimit()` candles of the given width which start
+	// at or after `start`.
+	DownloadCandles(ctx context.Context, inst Instrument, intervalSeconds int32, start time.Time) ([]querier.StageCandlesParams, error)
+}
@@ -0,0 +1,42 @@
+package exchanges
+
+import (
+	"strings"
+
+	"github.com/risingwavelabs/eris"
+)
+
+// Identifies a traded pair independently of any exchange. Its string form,
+// e.g. `btc-usdt`, is used as pair in the database, in metrics, and in the
+// order book registry.
+type Instrument struct {
+	Base  string // e.g. `btc`
+	Quote string // e.g. `usdt`
+}
+
+// Parses an instrument such as `btc-usdt`, `BTC-USDT`, or `BTC/USDT`.
+func ParseInstrument(s string) (Instrument, error) {
+	base, quote, ok := strings.Cut(s, "-")
+	if !ok {
+		base, quote, ok = strings.Cut(s, "/")
+	}
+	if !ok || base == "" || quote == "" || strings.ContainsAny(quote, "-/") {
+		return Instrument{}, eris.Errorf("invalid instrument: '%s'", s)
+	}
+
+	return Instrument{
+		Base:  strings.ToLower(base),
+		Quote: strings.ToLower(quote),
+	}, nil
+}
+
+// Returns the canonical form, e.g. `btc-usdt`.
+func (i Instrument) String() string {
+	return i.Base + "-" + i.Quote
+}
+
+// Returns the currencies in upper case joined by `sep`, which is how most
+// exchanges name their symbols, e.g. `BTCUSDT` or `BTC-USD`.
+func (i Instrument) Join(sep string) string {
+	return strings.ToUpper(i.Base) + sep + strings.ToUpper(i.Quote)
+}
@@ -0,0 +1,25 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstrument(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"btc-usdt", "BTC-USDT", "BTC/USDT"} {
+		inst, err := ParseInstrument(s)
+		require.NoError(t, err)
+		require.Equal(t, Instrument{Base: "btc", Quote: "usdt"}, inst)
+		require.Equal(t, "btc-usdt", inst.String())
+		require.Equal(t, "BTCUSDT", inst.Join(""))
+		require.Equal(t, "BTC/USDT", inst.Join("/"))
+	}
+
+	for _, s := range []string{"", "btcusdt", "-usdt", "btc-", "btc-usdt-perp"} {
+		_, err := ParseInstrument(s)
+		require.Error(t, err, s)
+	}
+}
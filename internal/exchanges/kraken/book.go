@@ -0,0 +1,253 @@
+package kraken
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+	"freyr/internal/order"
+)
+
+// The number of levels per side covered by Kraken's checksum.
+const checksumDepth = 10
+
+// Signals that the book no longer matches Kraken's.
+var errChecksumMismatch = eris.New("checksum mismatch")
+
+// A level as sent by Kraken. Prices and quantities are JSON numbers; they
+// are kept as sent to parse them exactly.
+type bookLevel struct {
+	Price json.Number `json:"price"`
+	Qty   json.Number `json:"qty"`
+}
+
+// The data of a message of the book channel.
+type bookData struct {
+	Symbol    string      `json:"symbol"`
+	Bids      []bookLevel `json:"bids"`
+	Asks      []bookLevel `json:"asks"`
+	Checksum  uint32      `json:"checksum"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// The order book of a single pair built from the book channel. Besides the
+// (possibly aggregated) order book, it keeps the exact levels received from
+// Kraken to verify checksums.
+type krakenBook struct {
+	symbol string // As used by Kraken, e.g. `BTC/USD`.
+
+	live *exchanges.LiveBook
+
+	// The levels within the subscribed depth, sorted from the best to the
+	// worst price.
+	asks []order.Level
+	bids []order.Level
+
+	// Number of levels per side maintained by Kraken.
+	depth int
+
+	// Kraken does not number updates; we count them instead.
+	updateID int64
+}
+
+func newKrakenBook(cfg config.KrakenBook, depth int) (*krakenBook, error) {
+	pair := cfg.Pair
+	if pair == "" {
+		pair = cfg.Symbol
+	}
+
+	inst, err := exchanges.ParseInstrument(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol := cfg.Symbol
+	if symbol == "" {
+		symbol = inst.Join("/")
+	}
+
+	live, err := exchanges.NewLiveBook("kraken", inst, cfg.Precision, cfg.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &krakenBook{symbol: symbol, live: live, depth: depth}, nil
+}
+
+// Replaces the book with the given snapshot. The book is complete afterwards.
+func (k *krakenBook) handleSnapshot(data *bookData) error {
+	asks, err := k.parseLevels(data.Asks)
+	if err != nil {
+		return eris.Wrapf(err, "invalid asks of %s", k.symbol)
+	}
+	bids, err := k.parseLevels(data.Bids)
+	if err != nil {
+		return eris.Wrapf(err, "invalid bids of %s", k.symbol)
+	}
+
+	k.asks = k.asks[:0]
+	k.bids = k.bids[:0]
+	k.applyLevels(asks, bids)
+
+	err = k.verifyChecksum(data.Checksum)
+	if err != nil {
+		return err
+	}
+
+	k.updateID++
+	k.live.Replace(k.asks, k.bids, k.updateID)
+	return nil
+}
+
+// Applies an update and verifies the resulting checksum. Updates before the
+// first snapshot are ignored.
+func (k *krakenBook) handleUpdate(data *bookData) error {
+	if !k.live.Complete() {
+		return nil
+	}
+
+	asks, err := k.parseLevels(data.Asks)
+	if err != nil {
+		return eris.Wrapf(err, "invalid asks of %s", k.symbol)
+	}
+	bids, err := k.parseLevels(data.Bids)
+	if err != nil {
+		return eris.Wrapf(err, "invalid bids of %s", k.symbol)
+	}
+
+	askChanges, bidChanges := k.applyLevels(asks, bids)
+
+	err = k.verifyChecksum(data.Checksum)
+	if err != nil {
+		return err
+	}
+
+	k.updateID++
+	k.live.Apply(askChanges, bidChanges, k.updateID, timestamp(data.Timestamp))
+	return nil
+}
+
+func (k *krakenBook) parseLevels(raw []bookLevel) ([]order.Level, error) {
+	precision := k.live.Precision()
+
+	levels := make([]order.Level, len(raw))
+	for i, level := range raw {
+		var err error
+		levels[i].Price, err = precision.ParsePrice(level.Price.String())
+		if err != nil {
+			return nil, err
+		}
+		levels[i].Amount, err = precision.ParseAmount(level.Qty.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return levels, nil
+}
+
+// Applies the given levels to the exact levels. Levels beyond the subscribed
+// depth are dropped since Kraken no longer updates them. Returns the
+// resulting changes: the new amount of each updated or dropped price.
+func (k *krakenBook) applyLevels(asks, bids []order.Level) (askChanges, bidChanges []order.Level) {
+	k.asks, askChanges = applySide(k.asks, asks, k.depth, func(a, b order.Price) bool { return a < b })
+	k.bids, bidChanges = applySide(k.bids, bids, k.depth, func(a, b order.Price) bool { return a > b })
+	return askChanges, bidChanges
+}
+
+func applySide(levels, updates []order.Level, depth int, better func(a, b order.Price) bool) ([]order.Level, []order.Level) {
+	touched := make([]order.Price, 0, len(updates))
+
+	for _, update := range updates {
+		touched = append(touched, update.Price)
+
+		i, found := slices.BinarySearchFunc(levels, update.Price, func(level order.Level, price order.Price) int {
+			switch {
+			case level.Price == price:
+				return 0
+			case better(level.Price, price):
+				return -1
+			default:
+				return 1
+			}
+		})
+
+		switch {
+		case update.Amount == 0 && found:
+			levels = slices.Delete(levels, i, i+1)
+		case update.Amount == 0:
+			// Nothing to delete.
+		case found:
+			levels[i] = update
+		default:
+			levels = slices.Insert(levels, i, update)
+		}
+	}
+
+	if len(levels) > depth {
+		for _, level := range levels[depth:] {
+			touched = append(touched, level.Price)
+		}
+		levels = levels[:depth]
+	}
+
+	amounts := make(map[order.Price]order.Amount, len(levels))
+	for _, level := range levels {
+		amounts[level.Price] = level.Amount
+	}
+
+	slices.Sort(touched)
+	touched = slices.Compact(touched)
+
+	changes := make([]order.Level, len(touched))
+	for i, price := range touched {
+		changes[i] = order.Level{Price: price, Amount: amounts[price]}
+	}
+
+	return levels, changes
+}
+
+// Compares the checksum computed from the exact levels with Kraken's.
+func (k *krakenBook) verifyChecksum(expected uint32) error {
+	actual := k.checksum()
+	if actual != expected {
+		return eris.Wrapf(errChecksumMismatch, "%s: expected %d, actual %d", k.symbol, expected, actual)
+	}
+	return nil
+}
+
+// Computes Kraken's checksum: the CRC32 of the top asks (from the lowest
+// price) followed by the top bids (from the highest price). Each level is
+// written as price and quantity without decimal point and leading zeros.
+func (k *krakenBook) checksum() uint32 {
+	precision := k.live.Precision()
+	builder := strings.Builder{}
+
+	for _, levels := range [][]order.Level{k.asks, k.bids} {
+		for _, level := range levels[:min(len(levels), checksumDepth)] {
+			builder.WriteString(checksumField(precision.FormatPrice(level.Price)))
+			builder.WriteString(checksumField(precision.FormatAmount(level.Amount)))
+		}
+	}
+
+	return crc32.ChecksumIEEE([]byte(builder.String()))
+}
+
+func checksumField(decimal string) string {
+	return strings.TrimLeft(strings.Replace(decimal, ".", "", 1), "0")
+}
+
+// Returns the given time in ms, or the current time if it is invalid.
+func timestamp(s string) int64 {
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Now().UnixMilli()
+	}
+	return ts.UnixMilli()
+}
@@ -0,0 +1,97 @@
+package kraken
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/config"
+	"freyr/internal/order"
+)
+
+func TestBookChecksum(t *testing.T) {
+	t.Parallel()
+
+	book, err := newKrakenBook(config.KrakenBook{
+		Symbol:      "BTC/USD",
+		Precision:   order.Precision{Price: 1, Amount: 8},
+		Granularity: "0.1",
+	}, 3)
+	require.NoError(t, err)
+	require.Equal(t, "btc-usd", book.live.Instrument().String())
+
+	parse := func(raw string) *bookData {
+		data := &bookData{}
+		require.NoError(t, json.Unmarshal([]byte(raw), data))
+		return data
+	}
+
+	// Quantities are JSON numbers, possibly in exponent notation.
+	snapshot := parse(`{
+		"symbol": "BTC/USD",
+		"asks": [{"price": 45283.5, "qty": 0.1}, {"price": 45283.8, "qty": 1.5e-3}],
+		"bids": [{"price": 45283.4, "qty": 0.5}, {"price": 45280, "qty": 2}],
+		"checksum": 1068042803
+	}`)
+	require.NoError(t, book.handleSnapshot(snapshot))
+	require.True(t, book.live.Complete())
+
+	// The last bid exceeds the depth and is dropped.
+	update := parse(`{
+		"symbol": "BTC/USD",
+		"asks": [{"price": 45283.5, "qty": 0}],
+		"bids": [{"price": 45283.0, "qty": 1}, {"price": 45279.0, "qty": 1}],
+		"checksum": 3807867836,
+		"timestamp": "2023-10-06T17:35:55.440295Z"
+	}`)
+	require.NoError(t, book.handleUpdate(update))
+
+	view := book.live.Book().Snapshot()
+	asks, bids := view.Len()
+	require.Equal(t, 1, asks)
+	require.Equal(t, 3, bids)
+
+	bestAsk, _ := view.BestAsk()
+	require.Equal(t, "45283.8", view.Precision().FormatPrice(bestAsk.Price))
+
+	// Mismatching checksums are detected.
+	update = parse(`{"symbol": "BTC/USD", "bids": [{"price": 45283.4, "qty": 0}], "checksum": 3807867836}`)
+	require.ErrorIs(t, book.handleUpdate(update), errChecksumMismatch)
+}
+
+// Uses the example of Kraken's guide to book checksums, which does not
+// depend on the formatting under test.
+func TestBookChecksumExample(t *testing.T) {
+	t.Parallel()
+
+	book, err := newKrakenBook(config.KrakenBook{
+		Symbol:      "ETH/BTC",
+		Precision:   order.Precision{Price: 5, Amount: 8},
+		Granularity: "0.00001",
+	}, 10)
+	require.NoError(t, err)
+
+	data := &bookData{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"symbol": "ETH/BTC",
+		"asks": [
+			{"price": 0.05005, "qty": 0.00000500}, {"price": 0.05010, "qty": 0.00000500},
+			{"price": 0.05015, "qty": 0.00000500}, {"price": 0.05020, "qty": 0.00000500},
+			{"price": 0.05025, "qty": 0.00000500}, {"price": 0.05030, "qty": 0.00000500},
+			{"price": 0.05035, "qty": 0.00000500}, {"price": 0.05040, "qty": 0.00000500},
+			{"price": 0.05045, "qty": 0.00000500}, {"price": 0.05050, "qty": 0.00000500}
+		],
+		"bids": [
+			{"price": 0.05000, "qty": 0.00000500}, {"price": 0.04995, "qty": 0.00000500},
+			{"price": 0.04990, "qty": 0.00000500}, {"price": 0.04980, "qty": 0.00000500},
+			{"price": 0.04975, "qty": 0.00000500}, {"price": 0.04970, "qty": 0.00000500},
+			{"price": 0.04965, "qty": 0.00000500}, {"price": 0.04960, "qty": 0.00000500},
+			{"price": 0.04955, "qty": 0.00000500}, {"price": 0.04950, "qty": 0.00000500}
+		],
+		"checksum": 974947235
+	}`), data))
+
+	require.NoError(t, book.handleSnapshot(data))
+	require.Equal(t, uint32(974947235), book.checksum())
+}
@@ -0,0 +1,184 @@
+package kraken
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync/atomic"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+)
+
+// Kraken's websocket address (API v2) for public market data.
+const FeedURL = "wss://ws.kraken.com/v2"
+
+// Depths of the book channel supported by Kraken.
+var depths = []int{10, 25, 100, 500, 1000}
+
+// A message received from Kraken: either data of a channel or a response to
+// a request.
+type message struct {
+	Channel string          `json:"channel"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+
+	// Responses.
+	Method  string `json:"method"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	ReqID   int64  `json:"req_id"`
+}
+
+// Maintains order books via the book channel of Kraken's websocket API.
+type Kraken struct {
+	socket exchanges.Socket
+	reqID  atomic.Int64
+
+	// One order book per configured pair.
+	books []*krakenBook
+}
+
+func (s *Kraken) Name() string { return "Kraken Websocket" }
+
+func (s *Kraken) Init(_ context.Context) error {
+	s.socket = exchanges.Socket{Name: "Kraken", URL: FeedURL}
+
+	depth := config.C.Kraken.Depth
+	if !slices.Contains(depths, depth) {
+		return eris.Errorf("unsupported depth: %d", depth)
+	}
+
+	s.books = make([]*krakenBook, 0, len(config.C.Kraken.Books))
+	for _, bookCfg := range config.C.Kraken.Books {
+		book, err := newKrakenBook(bookCfg, depth)
+		if err != nil {
+			return eris.Wrap(err, "invalid order book configuration")
+		}
+		s.books = append(s.books, book)
+	}
+
+	return nil
+}
+
+func (s *Kraken) Run(ctx context.Context) error {
+	defer func() {
+		for _, book := range s.books {
+			book.live.Close()
+		}
+	}()
+
+	if len(s.books) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	symbols := make([]string, len(s.books))
+	bySymbol := make(map[string]*krakenBook, len(s.books))
+	for i, book := range s.books {
+		symbols[i] = book.symbol
+		bySymbol[book.symbol] = book
+	}
+
+	return s.socket.Run(
+		ctx,
+		func() error { return s.sendRequest("subscribe", symbols...) },
+		func(msg []byte) error { return s.handleMessage(bySymbol, msg) },
+		func(reason string) {
+			for _, book := range s.books {
+				book.live.Resync(reason)
+			}
+		},
+	)
+}
+
+// Passes the data of the book channel on to the books. Books which fail to
+// process it are rebuilt.
+func (s *Kraken) handleMessage(bySymbol map[string]*krakenBook, rawMsg []byte) error {
+	msg := &message{}
+	err := json.Unmarshal(rawMsg, msg)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal message")
+	}
+
+	if msg.Method != "" {
+		if !msg.Success {
+			return eris.Errorf("%s request %d failed: %s", msg.Method, msg.ReqID, msg.Error)
+		}
+		return nil
+	}
+	if msg.Channel != "book" {
+		// E.g. heartbeats and status messages.
+		return nil
+	}
+
+	var data []*bookData
+	err = json.Unmarshal(msg.Data, &data)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal book data")
+	}
+
+	for _, bookData := range data {
+		book, ok := bySymbol[bookData.Symbol]
+		if !ok {
+			continue
+		}
+
+		if msg.Type == "snapshot" {
+			err = book.handleSnapshot(bookData)
+		} else {
+			err = book.handleUpdate(bookData)
+		}
+		if err != nil {
+			err = s.resubscribe(book, err)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Rebuilds the given book after it failed to process a message by
+// subscribing again, which provides a new snapshot.
+func (s *Kraken) resubscribe(book *krakenBook, cause error) error {
+	reason := "invalid_data"
+	if errors.Is(cause, errChecksumMismatch) {
+		reason = "checksum_mismatch"
+	}
+
+	book.live.Resync(reason)
+
+	err := s.sendRequest("unsubscribe", book.symbol)
+	if err == nil {
+		err = s.sendRequest("subscribe", book.symbol)
+	}
+	return eris.Wrapf(err, "failed to rebuild order book for %s (%s)", book.symbol, eris.ToString(cause, false))
+}
+
+// Subscribes to or unsubscribes from the book channel of the given symbols.
+func (s *Kraken) sendRequest(method string, symbols ...string) error {
+	params := map[string]any{
+		"channel": "book",
+		"symbol":  symbols,
+		"depth":   config.C.Kraken.Depth,
+	}
+	if method == "subscribe" {
+		params["snapshot"] = true
+	}
+
+	err := s.socket.WriteJSON(map[string]any{
+		"method": method,
+		"params": params,
+		"req_id": s.reqID.Add(1),
+	})
+	return eris.Wrapf(err, "failed to send %s request", method)
+}
+
+func (s *Kraken) Stop() error {
+	return s.socket.Close()
+}
@@ -0,0 +1,206 @@
+package okx
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+	"freyr/internal/order"
+)
+
+// The number of levels per side covered by OKX's checksum.
+const checksumDepth = 25
+
+// Signals that the book no longer matches OKX's.
+var errChecksumMismatch = eris.New("checksum mismatch")
+
+// The data of a message of the books channel.
+type bookData struct {
+	// Levels as [price, size, deprecated, number of orders].
+	Asks [][]string `json:"asks"`
+	Bids [][]string `json:"bids"`
+
+	Timestamp string `json:"ts"` // in ms
+	Checksum  int32  `json:"checksum"`
+	PrevSeqID int64  `json:"prevSeqId"`
+	SeqID     int64  `json:"seqId"`
+}
+
+// A level as sent by OKX. Its strings are kept to compute checksums.
+type exactLevel struct {
+	price order.Price
+	raw   []string // [price, size]
+}
+
+// The order book of a single instrument built from the books channel.
+type okxBook struct {
+	instID string // As used by OKX, e.g. `BTC-USDT`.
+
+	sync *exchanges.BookSync
+
+	// The levels as sent by OKX, sorted from the best to the worst price.
+	asks []exactLevel
+	bids []exactLevel
+}
+
+func newOkxBook(cfg config.OkxBook) (*okxBook, error) {
+	pair := cfg.Pair
+	if pair == "" {
+		pair = cfg.InstID
+	}
+
+	inst, err := exchanges.ParseInstrument(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	instID := cfg.InstID
+	if instID == "" {
+		instID = inst.Join("-")
+	}
+
+	live, err := exchanges.NewLiveBook("okx", inst, cfg.Precision, cfg.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &okxBook{instID: instID, sync: exchanges.NewBookSync(live)}, nil
+}
+
+// Replaces the book with the given snapshot. The book is complete afterwards.
+func (o *okxBook) handleSnapshot(data *bookData) error {
+	asks, bids, err := o.parseLevels(data)
+	if err != nil {
+		return err
+	}
+
+	o.asks, o.bids = nil, nil
+	o.applyLevels(data)
+
+	err = o.verifyChecksum(data.Checksum)
+	if err != nil {
+		return err
+	}
+
+	// Always succeeds since updates are not buffered.
+	o.sync.Snapshot(data.SeqID, asks, bids)
+	return nil
+}
+
+// Applies an update and verifies the resulting checksum. Updates before the
+// first snapshot are ignored.
+func (o *okxBook) handleUpdate(data *bookData) error {
+	if !o.sync.Live().Complete() {
+		return nil
+	}
+
+	asks, bids, err := o.parseLevels(data)
+	if err != nil {
+		return err
+	}
+
+	ts, err := strconv.ParseInt(data.Timestamp, 10, 64)
+	if err != nil {
+		return eris.Wrapf(err, "invalid timestamp of %s", o.instID)
+	}
+
+	err = o.sync.Update(exchanges.BookUpdate{
+		Delta:     exchanges.ChainDelta(data.PrevSeqID, data.SeqID),
+		Asks:      asks,
+		Bids:      bids,
+		Timestamp: ts,
+	})
+	if err != nil {
+		return err
+	}
+
+	o.applyLevels(data)
+	return o.verifyChecksum(data.Checksum)
+}
+
+func (o *okxBook) parseLevels(data *bookData) (asks, bids []order.Level, err error) {
+	precision := o.sync.Live().Precision()
+
+	asks, err = precision.ParseLevels(data.Asks)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "invalid asks of %s", o.instID)
+	}
+	bids, err = precision.ParseLevels(data.Bids)
+	if err != nil {
+		return nil, nil, eris.Wrapf(err, "invalid bids of %s", o.instID)
+	}
+
+	return asks, bids, nil
+}
+
+// Applies the levels of the given data to the exact levels. Levels with a
+// size of zero are removed. The levels are already validated by
+// `parseLevels()`.
+func (o *okxBook) applyLevels(data *bookData) {
+	precision := o.sync.Live().Precision()
+
+	apply := func(levels []exactLevel, updates [][]string, better func(a, b order.Price) bool) []exactLevel {
+		for _, update := range updates {
+			price, _ := precision.ParsePrice(update[0])
+			amount, _ := precision.ParseAmount(update[1])
+
+			i, found := slices.BinarySearchFunc(levels, price, func(level exactLevel, price order.Price) int {
+				switch {
+				case level.price == price:
+					return 0
+				case better(level.price, price):
+					return -1
+				default:
+					return 1
+				}
+			})
+
+			level := exactLevel{price: price, raw: update[:2]}
+			switch {
+			case amount == 0 && found:
+				levels = slices.Delete(levels, i, i+1)
+			case amount == 0:
+				// Nothing to delete.
+			case found:
+				levels[i] = level
+			default:
+				levels = slices.Insert(levels, i, level)
+			}
+		}
+		return levels
+	}
+
+	o.asks = apply(o.asks, data.Asks, func(a, b order.Price) bool { return a < b })
+	o.bids = apply(o.bids, data.Bids, func(a, b order.Price) bool { return a > b })
+}
+
+// Compares the checksum computed from the exact levels with OKX's.
+func (o *okxBook) verifyChecksum(expected int32) error {
+	actual := o.checksum()
+	if actual != expected {
+		return eris.Wrapf(errChecksumMismatch, "%s: expected %d, actual %d", o.instID, expected, actual)
+	}
+	return nil
+}
+
+// Computes OKX's checksum: the CRC32 (as signed integer) of the top bids and
+// asks, alternating between them and starting with the best bid. Each level
+// is written as `price:size` as sent by OKX; all are joined by colons.
+func (o *okxBook) checksum() int32 {
+	fields := make([]string, 0, 4*checksumDepth)
+	for i := range checksumDepth {
+		if i < len(o.bids) {
+			fields = append(fields, o.bids[i].raw...)
+		}
+		if i < len(o.asks) {
+			fields = append(fields, o.asks[i].raw...)
+		}
+	}
+
+	return int32(crc32.ChecksumIEEE([]byte(strings.Join(fields, ":"))))
+}
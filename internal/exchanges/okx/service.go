@@ -0,0 +1,176 @@
+package okx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/config"
+	"freyr/internal/exchanges"
+)
+
+const (
+	// OKX's websocket address for public market data.
+	FeedURL = "wss://ws.okx.com:8443/ws/v5/public"
+
+	// OKX closes connections without messages for 30s.
+	pingInterval = 20 * time.Second
+)
+
+// A message received from OKX: either data of a channel or an event, e.g. a
+// response to a request.
+type message struct {
+	Arg struct {
+		Channel string `json:"channel"`
+		InstID  string `json:"instId"`
+	} `json:"arg"`
+	Action string      `json:"action"`
+	Data   []*bookData `json:"data"`
+
+	// Events.
+	Event string `json:"event"`
+	Code  string `json:"code"`
+	Msg   string `json:"msg"`
+}
+
+// Maintains order books via the books channel of OKX's websocket API.
+type Okx struct {
+	socket exchanges.Socket
+
+	// One order book per configured instrument.
+	books []*okxBook
+}
+
+func (s *Okx) Name() string { return "OKX Websocket" }
+
+func (s *Okx) Init(_ context.Context) error {
+	s.socket = exchanges.Socket{
+		Name:         "OKX",
+		URL:          FeedURL,
+		Ping:         []byte("ping"),
+		PingInterval: pingInterval,
+	}
+
+	s.books = make([]*okxBook, 0, len(config.C.Okx.Books))
+	for _, bookCfg := range config.C.Okx.Books {
+		book, err := newOkxBook(bookCfg)
+		if err != nil {
+			return eris.Wrap(err, "invalid order book configuration")
+		}
+		s.books = append(s.books, book)
+	}
+
+	return nil
+}
+
+func (s *Okx) Run(ctx context.Context) error {
+	defer func() {
+		for _, book := range s.books {
+			book.sync.Live().Close()
+		}
+	}()
+
+	if len(s.books) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	instIDs := make([]string, len(s.books))
+	byInstID := make(map[string]*okxBook, len(s.books))
+	for i, book := range s.books {
+		instIDs[i] = book.instID
+		byInstID[book.instID] = book
+	}
+
+	return s.socket.Run(
+		ctx,
+		func() error { return s.sendRequest("subscribe", instIDs...) },
+		func(msg []byte) error { return s.handleMessage(byInstID, msg) },
+		func(reason string) {
+			for _, book := range s.books {
+				book.sync.Resync(reason)
+			}
+		},
+	)
+}
+
+// Passes the data of the books channel on to the books. Books which fail to
+// process it are rebuilt.
+func (s *Okx) handleMessage(byInstID map[string]*okxBook, rawMsg []byte) error {
+	if string(rawMsg) == "pong" {
+		return nil
+	}
+
+	msg := &message{}
+	err := json.Unmarshal(rawMsg, msg)
+	if err != nil {
+		return eris.Wrap(err, "failed to unmarshal message")
+	}
+
+	if msg.Event == "error" {
+		return eris.Errorf("error from OKX: %s (%s)", msg.Msg, msg.Code)
+	}
+	if msg.Event != "" || msg.Arg.Channel != "books" {
+		// E.g. confirmation of subscriptions.
+		return nil
+	}
+
+	book, ok := byInstID[msg.Arg.InstID]
+	if !ok {
+		return nil
+	}
+
+	for _, data := range msg.Data {
+		if msg.Action == "snapshot" {
+			err = book.handleSnapshot(data)
+		} else {
+			err = book.handleUpdate(data)
+		}
+		if err != nil {
+			return s.resubscribe(book, err)
+		}
+	}
+
+	return nil
+}
+
+// Rebuilds the given book after it failed to process a message by
+// subscribing again, which provides a new snapshot.
+func (s *Okx) resubscribe(book *okxBook, cause error) error {
+	reason := "invalid_data"
+	if errors.Is(cause, exchanges.ErrSequenceGap) {
+		reason = "sequence_gap"
+	} else if errors.Is(cause, errChecksumMismatch) {
+		reason = "checksum_mismatch"
+	}
+
+	book.sync.Resync(reason)
+
+	err := s.sendRequest("unsubscribe", book.instID)
+	if err == nil {
+		err = s.sendRequest("subscribe", book.instID)
+	}
+	return eris.Wrapf(err, "failed to rebuild order book for %s (%s)", book.instID, eris.ToString(cause, false))
+}
+
+// Subscribes to or unsubscribes from the books channel of the given
+// instruments.
+func (s *Okx) sendRequest(op string, instIDs ...string) error {
+	args := make([]map[string]string, len(instIDs))
+	for i, instID := range instIDs {
+		args[i] = map[string]string{"channel": "books", "instId": instID}
+	}
+
+	err := s.socket.WriteJSON(map[string]any{
+		"op":   op,
+		"args": args,
+	})
+	return eris.Wrapf(err, "failed to send %s request", op)
+}
+
+func (s *Okx) Stop() error {
+	return s.socket.Close()
+}
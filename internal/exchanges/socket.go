@@ -0,0 +1,203 @@
+package exchanges
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/utils"
+)
+
+// A websocket connection to an exchange which is re-established whenever it
+// is lost.
+type Socket struct {
+	// The exchange's name as used in log messages, e.g. `Kraken`.
+	Name string
+
+	// The websocket's address.
+	URL string
+
+	// A message sent every `PingInterval` to keep the connection alive (nil if
+	// the exchange does not require it).
+	Ping         []byte
+	PingInterval time.Duration
+
+	con *websocket.Conn
+
+	// Guards replacing `con` and writing to it.
+	conLock sync.Mutex
+}
+
+// Connects and passes every received message to `handle` until the context
+// is cancelled. `subscribe` is called after each connect. If the connection
+// is lost, `lost` is called with the reason (as used in metrics) before
+// reconnecting.
+func (s *Socket) Run(
+	ctx context.Context,
+	subscribe func() error,
+	handle func(msg []byte) error,
+	lost func(reason string),
+) error {
+	backoff := utils.Backoff{Min: time.Second, Max: 2 * time.Minute}
+
+	for {
+		start := time.Now()
+		err := s.runSession(ctx, subscribe, handle)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		//
+		// Connection lost: Reconnect.
+
+		reason := "disconnected"
+		if err != nil {
+			reason = "connection_error"
+			fmt.Printf("Lost connection to %s: %s\n", s.Name, eris.ToString(err, true))
+		} else {
+			fmt.Printf("%s closed the connection.\n", s.Name)
+		}
+		lost(reason)
+
+		if time.Since(start) > time.Minute {
+			backoff.Reset()
+		}
+		delay := backoff.Next()
+
+		fmt.Printf("Reconnecting to %s in %s.\n", s.Name, delay)
+		if !utils.Sleep(ctx, delay) {
+			return nil
+		}
+	}
+}
+
+// Connects and handles messages until the connection ends.
+func (s *Socket) runSession(ctx context.Context, subscribe func() error, handle func(msg []byte) error) (err error) {
+	con, httpRes, err := websocket.DefaultDialer.DialContext(ctx, s.URL, nil)
+	if err != nil {
+		return eris.Wrap(err, "failed to start websocket")
+	}
+
+	s.conLock.Lock()
+	s.con = con
+	s.conLock.Unlock()
+
+	fmt.Printf("Connected to %s via %s.\n", s.Name, s.URL)
+
+	// Interrupt reading once the context is cancelled and keep the connection
+	// alive meanwhile.
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.keepAlive(ctx)
+		_ = con.SetReadDeadline(time.Now())
+	}()
+
+	defer func() {
+		cancel()
+		<-done
+
+		s.conLock.Lock()
+		defer s.conLock.Unlock()
+
+		// Clean close. Fails if the connection is already lost.
+		_ = con.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		s.con = nil
+
+		err = eris.Join(err, httpRes.Body.Close(), con.Close())
+	}()
+
+	err = subscribe()
+	if err != nil {
+		return err
+	}
+
+	for {
+		_, msg, err := con.ReadMessage() // blocking
+		if err != nil {
+			if ctx.Err() != nil {
+				// Reading was interrupted.
+				return nil
+			}
+
+			closeErr, ok := err.(*websocket.CloseError)
+			if ok && closeErr.Code == websocket.CloseNormalClosure {
+				return nil
+			}
+			return eris.Wrap(err, "error reading message")
+		}
+
+		err = handle(msg)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Sends the ping message periodically until the context is cancelled.
+func (s *Socket) keepAlive(ctx context.Context) {
+	if s.Ping == nil {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(s.PingInterval)
+	defer ticker.Stop()
+
+	for range utils.CtxChanIter(ctx, ticker.C) {
+		err := s.Write(s.Ping)
+		if err != nil {
+			fmt.Printf("Failed to ping %s: %s\n", s.Name, eris.ToString(err, false))
+		}
+	}
+}
+
+// Sends the given value as JSON.
+func (s *Socket) WriteJSON(v any) error {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		return eris.Wrap(err, "failed to marshal message")
+	}
+	return s.Write(msg)
+}
+
+// Sends a text message.
+func (s *Socket) Write(msg []byte) error {
+	s.conLock.Lock()
+	defer s.conLock.Unlock()
+
+	if s.con == nil {
+		return eris.New("not connected")
+	}
+
+	err := s.con.WriteMessage(websocket.TextMessage, msg)
+	if err != nil {
+		return eris.Wrap(err, "failed to send message")
+	}
+
+	return nil
+}
+
+// Closes the connection cleanly, which lets the exchange end it.
+func (s *Socket) Close() error {
+	s.conLock.Lock()
+	defer s.conLock.Unlock()
+
+	if s.con == nil {
+		return nil
+	}
+
+	// Clean close
+	err := s.con.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		return eris.Wrap(err, "failed to close connection")
+	}
+
+	return nil
+}
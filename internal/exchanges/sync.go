@@ -0,0 +1,180 @@
+package exchanges
+
+import (
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/order"
+)
+
+// Signals that an update does not follow the previous one.
+var ErrSequenceGap = eris.New("sequence gap")
+
+// The position of an update within an exchange's sequence of updates. IDs
+// are assigned by the exchange.
+type Delta struct {
+	First int64 // ID of the first change contained in the update.
+	Last  int64 // ID of the book after the update.
+	Prev  int64 // ID of the book to which the update applies.
+}
+
+// An update covering the IDs `first` to `last`, e.g. Binance's `U` and `u`.
+// It applies to the book with ID `first-1`.
+func RangeDelta(first, last int64) Delta {
+	return Delta{First: first, Last: last, Prev: first - 1}
+}
+
+// An update referring to its predecessor, e.g. OKX's `prevSeqId` and
+// `seqId`.
+func ChainDelta(prev, id int64) Delta {
+	return Delta{First: id, Last: id, Prev: prev}
+}
+
+// Returns true if the update applies to the book with the given ID.
+func (d Delta) Follows(id int64) bool {
+	return d.Prev == id
+}
+
+// An update of a book along with its position in the exchange's sequence.
+type BookUpdate struct {
+	Delta     Delta
+	Asks      []order.Level
+	Bids      []order.Level
+	Timestamp int64 // Unix time in ms
+}
+
+// Keeps a live book in sync with an exchange's sequence of updates. Updates
+// received before a full book are buffered and applied once it arrives.
+type BookSync struct {
+	live *LiveBook
+
+	// ID of the latest applied or buffered update.
+	lastID int64
+
+	// True while the book equals the latest full book. The next update may
+	// then start before the full book, e.g. if Binance's full book is newer
+	// than all buffered updates.
+	fresh bool
+
+	// Updates received while the book is not complete.
+	buffer []BookUpdate
+}
+
+func NewBookSync(live *LiveBook) *BookSync {
+	return &BookSync{live: live}
+}
+
+func (s *BookSync) Live() *LiveBook { return s.live }
+
+// Returns true while updates are buffered until a full book arrives.
+func (s *BookSync) Buffering() bool {
+	return !s.live.Complete() && len(s.buffer) > 0
+}
+
+// Returns the position of the first buffered update.
+func (s *BookSync) FirstBuffered() (Delta, bool) {
+	if len(s.buffer) == 0 {
+		return Delta{}, false
+	}
+	return s.buffer[0].Delta, true
+}
+
+// Applies the given update, or buffers it if the book is not complete. Fails
+// with `ErrSequenceGap` if the update does not follow the previous one; the
+// book has to be resynced then.
+func (s *BookSync) Update(update BookUpdate) error {
+	delta := update.Delta
+
+	if s.fresh && delta.Last <= s.lastID {
+		// Already contained in the full book.
+		return nil
+	}
+
+	if s.live.Complete() || len(s.buffer) > 0 {
+		follows := delta.Follows(s.lastID) || (s.fresh && delta.Prev < s.lastID)
+		if !follows {
+			return eris.Wrapf(
+				ErrSequenceGap, "%s: expected update of %d, received update of %d",
+				s.live.inst, s.lastID, delta.Prev,
+			)
+		}
+	}
+	s.lastID = delta.Last
+	s.fresh = false
+
+	if !s.live.Complete() {
+		s.buffer = append(s.buffer, update)
+		return nil
+	}
+
+	s.live.Apply(update.Asks, update.Bids, update.Delta.Last, update.Timestamp)
+	return nil
+}
+
+// Replaces the book with a full one with the given ID and applies the
+// buffered updates following it. The book is complete afterwards. Returns
+// false if the full book is older than the buffered updates; another one is
+// needed then.
+func (s *BookSync) Snapshot(id int64, asks, bids []order.Level) bool {
+	if !s.connects(id) {
+		return false
+	}
+
+	s.live.load(asks, bids, id)
+	s.merge(id)
+	return true
+}
+
+// Continues with a book stored by a previous run instead of a full one.
+// Returns false if the book is older than the buffered updates.
+func (s *BookSync) Restore(book *order.Book) bool {
+	if !s.connects(book.UpdateID()) {
+		return false
+	}
+
+	s.live.restore(book)
+	s.merge(book.UpdateID())
+	return true
+}
+
+// Returns true if the buffered updates continue the book with the given ID.
+func (s *BookSync) connects(id int64) bool {
+	return len(s.buffer) == 0 || s.buffer[0].Delta.Prev <= id
+}
+
+// Applies the buffered updates which are newer than the book with the given
+// ID and publishes the book.
+func (s *BookSync) merge(id int64) {
+	s.lastID = id
+	s.fresh = true
+
+	for _, update := range s.buffer {
+		if update.Delta.Last <= id {
+			continue
+		}
+		s.live.Apply(update.Asks, update.Bids, update.Delta.Last, update.Timestamp)
+
+		s.lastID = update.Delta.Last
+		s.fresh = false
+	}
+	s.buffer = nil
+
+	s.live.publish()
+}
+
+// Discards the book and all buffered updates until the next full book.
+func (s *BookSync) Resync(reason string) {
+	s.live.Resync(reason)
+	s.clear()
+}
+
+// Discards the book and all buffered updates without counting it as resync.
+func (s *BookSync) Reset() {
+	s.live.Reset()
+	s.clear()
+}
+
+func (s *BookSync) clear() {
+	s.lastID = 0
+	s.fresh = false
+	s.buffer = nil
+}
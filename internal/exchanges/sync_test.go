@@ -0,0 +1,120 @@
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"freyr/internal/metrics"
+	"freyr/internal/order"
+)
+
+// Updates received before the full book are buffered, and only those newer
+// than the full book are applied, like Binance's depth streams.
+func TestBookSyncBuffer(t *testing.T) {
+	t.Parallel()
+
+	live, err := NewLiveBook("test", Instrument{Base: "buffer", Quote: "usd"}, order.Precision{}, "1")
+	require.NoError(t, err)
+	defer live.Close()
+	s := NewBookSync(live)
+
+	for _, u := range []struct {
+		first, last int64
+		level       order.Level
+	}{
+		{11, 15, order.Level{Price: 100, Amount: 1}},
+		{16, 20, order.Level{Price: 101, Amount: 2}},
+		{21, 25, order.Level{Price: 102, Amount: 3}},
+	} {
+		require.NoError(t, s.Update(BookUpdate{
+			Asks:  []order.Level{u.level},
+			Delta: RangeDelta(u.first, u.last),
+		}))
+	}
+	require.True(t, s.Buffering())
+
+	// A full book older than the buffered updates cannot be used.
+	require.False(t, s.Snapshot(5, nil, nil))
+	require.False(t, s.Live().Complete())
+
+	require.True(t, s.Snapshot(18, []order.Level{{Price: 100, Amount: 5}}, nil))
+	require.True(t, s.Live().Complete())
+	require.False(t, s.Buffering())
+
+	// Buffered updates count as received once they are applied.
+	updates := metrics.OrderBookUpdates.WithLabelValues("test", "buffer-usd")
+	require.InDelta(t, 2, testutil.ToFloat64(updates), 0)
+
+	book := s.Live().Book()
+	require.EqualValues(t, 25, book.UpdateID())
+	ask, _ := book.Snapshot().BestAsk()
+	require.Equal(t, order.Level{Price: 100, Amount: 5}, ask)
+	lookup, ok := order.Lookup("test", "buffer-usd")
+	require.True(t, ok)
+	require.Same(t, book, lookup)
+
+	update := BookUpdate{Asks: []order.Level{{Price: 100, Amount: 0}}}
+	update.Delta = RangeDelta(27, 30)
+	require.ErrorIs(t, s.Update(update), ErrSequenceGap)
+
+	update.Delta = RangeDelta(26, 30)
+	require.NoError(t, s.Update(update))
+	ask, _ = book.Snapshot().BestAsk()
+	require.EqualValues(t, 101, ask.Price)
+	require.InDelta(t, 3, testutil.ToFloat64(updates), 0)
+}
+
+// A full book newer than all buffered updates is continued by the update
+// straddling it; older updates are skipped.
+func TestBookSyncFreshSnapshot(t *testing.T) {
+	t.Parallel()
+
+	live, err := NewLiveBook("test", Instrument{Base: "fresh", Quote: "usd"}, order.Precision{}, "1")
+	require.NoError(t, err)
+	defer live.Close()
+	s := NewBookSync(live)
+
+	update := BookUpdate{Asks: []order.Level{{Price: 100, Amount: 1}}}
+	update.Delta = RangeDelta(11, 15)
+	require.NoError(t, s.Update(update))
+
+	require.True(t, s.Snapshot(18, nil, nil))
+	require.EqualValues(t, 18, s.Live().Book().UpdateID())
+
+	update.Delta = RangeDelta(16, 17)
+	require.NoError(t, s.Update(update))
+	require.EqualValues(t, 18, s.Live().Book().UpdateID())
+
+	update.Delta = RangeDelta(17, 20)
+	require.NoError(t, s.Update(update))
+	require.EqualValues(t, 20, s.Live().Book().UpdateID())
+}
+
+// Updates referring to their predecessor, like OKX's, must arrive in order.
+func TestBookSyncChain(t *testing.T) {
+	t.Parallel()
+
+	live, err := NewLiveBook("test", Instrument{Base: "chain", Quote: "usd"}, order.Precision{}, "1")
+	require.NoError(t, err)
+	defer live.Close()
+	s := NewBookSync(live)
+	require.True(t, s.Snapshot(100, nil, nil))
+
+	update := BookUpdate{Asks: []order.Level{{Price: 100, Amount: 1}}}
+	update.Delta = ChainDelta(100, 104)
+	require.NoError(t, s.Update(update))
+
+	// OKX keeps the sequence ID if nothing changed.
+	update.Delta = ChainDelta(104, 104)
+	require.NoError(t, s.Update(update))
+
+	update.Delta = ChainDelta(105, 109)
+	require.ErrorIs(t, s.Update(update), ErrSequenceGap)
+
+	s.Resync("sequence_gap")
+	require.False(t, s.Live().Complete())
+	_, ok := order.Lookup("test", "chain-usd")
+	require.False(t, ok)
+}
@@ -0,0 +1,65 @@
+package exchanges
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+
+	"freyr/internal/database"
+	"freyr/internal/database/querier"
+	"freyr/internal/metrics"
+)
+
+const (
+	// Trades are written into the database once this many are collected or
+	// after the flush interval, whatever happens first.
+	TradeBatchSize     = 1000
+	TradeFlushInterval = time.Second
+)
+
+// Writes the received trades into the database in batches until the channel
+// is closed. Trades which are already stored are skipped. Failed batches are
+// reported and dropped.
+func WriteTrades(ctx context.Context, tradeChan <-chan querier.StageTradesParams) {
+	batch := make([]querier.StageTradesParams, 0, TradeBatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		inserted, err := database.InsertTrades(ctx, batch)
+		if err != nil {
+			fmt.Printf("Failed to store %d trades: %s\n", len(batch), eris.ToString(err, true))
+		} else {
+			for _, trade := range inserted {
+				metrics.TradesCollected.WithLabelValues(trade.Exchange, trade.Pair).Inc()
+			}
+		}
+
+		batch = batch[:0]
+	}
+
+	ticker := time.NewTicker(TradeFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case trade, more := <-tradeChan:
+			if !more {
+				flush()
+				return
+			}
+
+			batch = append(batch, trade)
+			if len(batch) >= TradeBatchSize {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
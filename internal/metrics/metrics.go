@@ -7,7 +7,16 @@ import (
 var collectors = []prometheus.Collector{
 	LatestCandleCollected,
 	LatestCandleQueried,
+	CandlesStored,
+	CandleGaps,
+	ExchangeRequests,
 	OrderBookUpdates,
+	OrderBookResyncs,
+	OrderBookLastResync,
+	TradesCollected,
+	ArbitrageSpread,
+	ArbitrageSize,
+	ArbitrageOpportunities,
 }
 
 const (
@@ -19,18 +28,45 @@ var (
 		prometheus.GaugeOpts{
 			Namespace: namespaceFreyr,
 			Name:      "latest_candle_collected_timestamp_seconds",
-			Help:      "The Unix timestamp of the latest candle collected for the specified trading pair.",
+			Help:      "The Unix timestamp of the latest candle collected for the specified trading pair and interval (in seconds).",
 		},
-		[]string{"exchange", "pair"},
+		[]string{"exchange", "pair", "interval"},
 	)
 
 	LatestCandleQueried = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: namespaceFreyr,
 			Name:      "latest_candle_queried_timestamp_seconds",
-			Help:      "The Unix timestamp of the latest candle queried (but not necessarily collected) for the specified trading pair.",
+			Help:      "The Unix timestamp of the latest candle queried (but not necessarily collected) for the specified trading pair and interval (in seconds).",
 		},
-		[]string{"exchange", "pair"},
+		[]string{"exchange", "pair", "interval"},
+	)
+
+	CandlesStored = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceFreyr,
+			Name:      "candles_stored_total",
+			Help:      "The total number of candles stored for the specified trading pair and interval (in seconds), by outcome: inserted, updated (stored before with different values), or unchanged.",
+		},
+		[]string{"exchange", "pair", "interval", "outcome"},
+	)
+
+	CandleGaps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceFreyr,
+			Name:      "candle_gaps",
+			Help:      "The number of ranges of missing candles found by the latest scan for the specified trading pair and interval (in seconds). Scans report a limited number of gaps.",
+		},
+		[]string{"exchange", "pair", "interval"},
+	)
+
+	ExchangeRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceFreyr,
+			Name:      "exchange_requests_total",
+			Help:      "The total number of HTTP requests sent to the specified exchange, by outcome: success, rate_limited, server_error, client_error, or network_error.",
+		},
+		[]string{"exchange", "outcome"},
 	)
 
 	OrderBookUpdates = prometheus.NewCounterVec(
@@ -41,4 +77,74 @@ var (
 		},
 		[]string{"exchange", "pair"},
 	)
+
+	OrderBookResyncs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceFreyr,
+			Name:      "order_book_resyncs_total",
+			Help:      "The total number of times the specified order book was rebuilt, by reason.",
+		},
+		[]string{"exchange", "pair", "reason"},
+	)
+
+	OrderBookLastResync = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceFreyr,
+			Name:      "order_book_last_resync_timestamp_seconds",
+			Help:      "The Unix timestamp of the latest rebuild of the specified order book. Only the latest reason is reported.",
+		},
+		[]string{"exchange", "pair", "reason"},
+	)
+
+	TradesCollected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceFreyr,
+			Name:      "trades_collected_total",
+			Help:      "The total number of trades stored for the specified trading pair.",
+		},
+		[]string{"exchange", "pair"},
+	)
+
+	ArbitrageSpread = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceFreyr,
+			Name:      "arbitrage_spread_bps",
+			Help:      "The difference between the best bid of the selling exchange and the best ask of the buying exchange for the specified pair, net of taker fees and relative to the ask (in bps). Positive values indicate an opportunity.",
+		},
+		[]string{"pair", "buy_exchange", "sell_exchange"},
+	)
+
+	ArbitrageSize = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespaceFreyr,
+			Name:      "arbitrage_size",
+			Help:      "The amount (in the base currency) of the specified pair which can currently be bought on one exchange and sold on the other at a profit, net of taker fees.",
+		},
+		[]string{"pair", "buy_exchange", "sell_exchange"},
+	)
+
+	ArbitrageOpportunities = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespaceFreyr,
+			Name:      "arbitrage_opportunities_total",
+			Help:      "The total number of ended arbitrage opportunities between the specified exchanges.",
+		},
+		[]string{"pair", "buy_exchange", "sell_exchange"},
+	)
 )
+
+// Records how many candles of the specified pair and interval were new, were
+// updated, or did not change when storing them.
+func ObserveCandlesStored(exchange, pair, interval string, inserted, updated, unchanged int) {
+	CandlesStored.WithLabelValues(exchange, pair, interval, "inserted").Add(float64(inserted))
+	CandlesStored.WithLabelValues(exchange, pair, interval, "updated").Add(float64(updated))
+	CandlesStored.WithLabelValues(exchange, pair, interval, "unchanged").Add(float64(unchanged))
+}
+
+// Records that the specified order book is rebuilt for the given reason.
+func ObserveOrderBookResync(exchange, pair, reason string) {
+	OrderBookResyncs.WithLabelValues(exchange, pair, reason).Inc()
+
+	OrderBookLastResync.DeletePartialMatch(prometheus.Labels{"exchange": exchange, "pair": pair})
+	OrderBookLastResync.WithLabelValues(exchange, pair, reason).SetToCurrentTime()
+}
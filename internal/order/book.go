@@ -2,19 +2,14 @@ package order
 
 import (
 	"cmp"
-	"math"
 	"slices"
-	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/btree"
 )
 
-// TODO: Better types.
-type (
-	Price  float64
-	Amount float64
-)
-
 type bookSide byte
 
 const (
@@ -22,84 +17,275 @@ const (
 	bidSide
 )
 
-type bookEntry struct {
-	price  Price
-	amount Amount
-}
+// An order book which retains its past states for a limited time.
+//
+// A book has a single writer which calls `Update()`. All reads, including
+// those of the writer, use `Snapshot()` and `At()`, which are safe for
+// concurrent use and never block the writer.
+type Book struct {
+	granularity Price
+
+	// The current state of the book. Only used by the writer.
+	cur View
+
+	// The ID of the latest update as assigned by the exchange (if any).
+	updateID int64
 
-func bookEntryLess(a, b bookEntry) bool {
-	return a.price < b.price
+	// The latest state which is visible to readers.
+	published atomic.Pointer[bookState]
+
+	//
+	// Cache
+
+	// How long (in ms) past states of the book remain available.
+	cacheWindow int64
+
+	// The first timestamp for which the book can be reconstructed. It is -1 if
+	// no timed update was received yet.
+	historyStart int64
+
+	// Changes to the book, sorted by timestamp. Each delta stores the previous
+	// state of the levels it changed, which allows to undo it.
+	history []bookDelta
 }
 
-type Book struct {
+// An immutable copy of a book's state which is shared with readers.
+type bookState struct {
+	View
 	granularity Price
+	updateID    int64
+
+	historyStart int64
+	history      []bookDelta
+
+	// Serialises cloning the trees of the view, which is not thread-safe.
+	cloneLock sync.Mutex
+}
 
-	// TODO: Better B-Tree?
-	curAsks *btree.BTreeG[bookEntry]
-	curBids *btree.BTreeG[bookEntry]
+// A change of a single level. `entry` is the state of the level *before* the
+// change; an amount of zero indicates that the level did not exist.
+type levelChange struct {
+	side  bookSide
+	entry Level
+}
 
-	// TODO: Cache
+// All level changes caused by a single update.
+type bookDelta struct {
+	timestamp int64
+	changes   []levelChange
 }
 
-func NewBook(granularity Price) Book {
-	return Book{
-		granularity: granularity,
-		curAsks:     btree.NewG(64, bookEntryLess),
-		curBids:     btree.NewG(64, bookEntryLess),
+// Creates an empty book which aggregates levels to multiples of `granularity`
+// and retains its past states for the duration of `cacheWindow`. Prices and
+// amounts of the book use the given precision.
+func NewBook(precision Precision, granularity Price, cacheWindow time.Duration) *Book {
+	b := &Book{
+		granularity: max(granularity, 1),
+		cur: View{
+			precision: precision,
+			timestamp: -1,
+			asks:      btree.NewG(64, levelLess),
+			bids:      btree.NewG(64, levelLess),
+		},
+		cacheWindow:  cacheWindow.Milliseconds(),
+		historyStart: -1,
 	}
+	b.publish()
+
+	return b
 }
 
-func (b *Book) MinAsk() Price {
-	minEntry, _ := b.curAsks.Min()
-	return minEntry.price
+// Returns the ID of the latest update applied to the book. Safe for
+// concurrent use.
+func (b *Book) UpdateID() int64 {
+	return b.published.Load().updateID
 }
 
-func (b *Book) MaxBid() Price {
-	maxEntry, _ := b.curBids.Max()
-	return maxEntry.price
+// Returns the latest state of the book. Safe for concurrent use.
+func (b *Book) Snapshot() View {
+	return b.published.Load().View
 }
 
-func (b *Book) Update(
-	rawAsks, rawBids [][]string,
-	_ int64, // TODO: Utilise timestamp for caching.
-) {
-	b.applyUpdates(b.curAsks, rawAsks, askSide)
-	b.applyUpdates(b.curBids, rawBids, bidSide)
+// Applies the given updates to the book. The update ID is the exchange's
+// identifier of the update (or 0 if there is none). The timestamp (Unix time
+// in ms) is used to cache the previous state of the book. Timestamps are
+// expected to be non-decreasing. A negative timestamp indicates an update
+// without known time (e.g. an initial snapshot); it invalidates all cached
+// states.
+func (b *Book) Update(asks, bids []Level, updateID, timestamp int64) {
+	b.updateID = updateID
+
+	if timestamp < 0 {
+		b.applyUpdates(b.cur.asks, asks, askSide, nil)
+		b.applyUpdates(b.cur.bids, bids, bidSide, nil)
+		b.history = nil
+		b.historyStart = -1
+		b.publish()
+		return
+	}
+
+	if b.historyStart < 0 {
+		b.historyStart = timestamp
+	}
+
+	delta := bookDelta{timestamp: timestamp}
+	b.applyUpdates(b.cur.asks, asks, askSide, &delta)
+	b.applyUpdates(b.cur.bids, bids, bidSide, &delta)
+
+	b.cur.timestamp = max(b.cur.timestamp, timestamp)
+	if len(delta.changes) > 0 {
+		b.history = append(b.history, delta)
+	}
+
+	b.pruneHistory()
+	b.publish()
+}
+
+// Makes the current state visible to readers. Cloning the trees is cheap, but
+// causes the writer to copy nodes lazily when they are modified next.
+func (b *Book) publish() {
+	b.published.Store(&bookState{
+		View: View{
+			precision: b.cur.precision,
+			timestamp: b.cur.timestamp,
+			asks:      b.cur.asks.Clone(),
+			bids:      b.cur.bids.Clone(),
+		},
+		granularity:  b.granularity,
+		updateID:     b.updateID,
+		historyStart: b.historyStart,
+		history:      b.history,
+	})
 }
 
-func (b *Book) applyUpdates(cur *btree.BTreeG[bookEntry], rawUpdates [][]string, side bookSide) {
-	var roundFunc func(x float64) float64
-	switch side {
-	case askSide:
-		roundFunc = math.Ceil
-	case bidSide:
-		roundFunc = math.Floor
+// Removes all levels and cached states, e.g. to rebuild the book from a new
+// snapshot.
+func (b *Book) Reset() {
+	b.cur.asks.Clear(false)
+	b.cur.bids.Clear(false)
+
+	b.cur.timestamp = -1
+	b.updateID = 0
+	b.history = nil
+	b.historyStart = -1
+
+	b.publish()
+}
+
+// Returns the state of the book at the given time (Unix time in ms), that is,
+// after all updates with a timestamp of at most `timestamp` were applied. The
+// second return value is false if that state is no longer (or not yet) cached.
+// Safe for concurrent use.
+func (b *Book) At(timestamp int64) (View, bool) {
+	state := b.published.Load()
+	if state.historyStart < 0 || timestamp < max(state.historyStart, state.timestamp-b.cacheWindow) {
+		return View{}, false
 	}
 
-	entries := make([]bookEntry, len(rawUpdates))
-	for i, update := range rawUpdates {
-		price, _ := strconv.ParseFloat(update[0], 64)
-		amount, _ := strconv.ParseFloat(update[1], 64)
+	state.cloneLock.Lock()
+	view := View{
+		precision: state.precision,
+		timestamp: min(timestamp, state.timestamp),
+		asks:      state.asks.Clone(),
+		bids:      state.bids.Clone(),
+	}
+	state.cloneLock.Unlock()
+
+	// Undo all changes which happened after the requested time. The writer
+	// only appends to the history; the visible part never changes.
+	history := state.history
+	for i := len(history) - 1; i >= 0 && history[i].timestamp > timestamp; i-- {
+		changes := history[i].changes
+		for j := len(changes) - 1; j >= 0; j-- {
+			tree := view.asks
+			if changes[j].side == bidSide {
+				tree = view.bids
+			}
 
-		entries[i] = bookEntry{
-			price:  Price(roundFunc(price/float64(b.granularity))) * b.granularity,
-			amount: Amount(amount),
+			if changes[j].entry.Amount == 0 {
+				tree.Delete(changes[j].entry)
+			} else {
+				tree.ReplaceOrInsert(changes[j].entry)
+			}
 		}
 	}
 
-	slices.SortFunc(entries, func(a, b bookEntry) int { return cmp.Compare(a.price, b.price) })
+	return view, true
+}
+
+// Removes all deltas which are not needed to reconstruct states within the
+// cache window.
+func (b *Book) pruneHistory() {
+	// A delta only needs to be undone for states before its timestamp.
+	cutoff := b.cur.timestamp - b.cacheWindow
+
+	i := 0
+	for i < len(b.history) && b.history[i].timestamp <= cutoff {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+
+	// Copy remaining deltas to allow the garbage collector to free old ones.
+	b.history = append(b.history[:0:0], b.history[i:]...)
+}
+
+func (b *Book) applyUpdates(
+	cur *btree.BTreeG[Level],
+	updates []Level,
+	side bookSide,
+	delta *bookDelta,
+) {
+	entries := make([]Level, len(updates))
+	for i, update := range updates {
+		entries[i] = Level{
+			Price:  b.roundPrice(update.Price, side),
+			Amount: update.Amount,
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b Level) int { return cmp.Compare(a.Price, b.Price) })
 
 	for i := 0; i < len(entries); {
-		aggEntry := bookEntry{entries[i].price, 0.0}
+		aggEntry := Level{entries[i].Price, 0}
 
-		for ; i < len(entries) && entries[i].price == aggEntry.price; i++ {
-			aggEntry.amount += entries[i].amount
+		for ; i < len(entries) && entries[i].Price == aggEntry.Price; i++ {
+			aggEntry.Amount += entries[i].Amount
 		}
 
-		if aggEntry.amount == 0.0 {
-			cur.Delete(aggEntry)
+		var prev Level
+		var existed bool
+		if aggEntry.Amount == 0 {
+			prev, existed = cur.Delete(aggEntry)
 		} else {
-			cur.ReplaceOrInsert(aggEntry)
+			prev, existed = cur.ReplaceOrInsert(aggEntry)
+		}
+
+		if delta == nil || (existed && prev == aggEntry) || (!existed && aggEntry.Amount == 0) {
+			continue
+		}
+		if !existed {
+			prev = Level{aggEntry.Price, 0}
 		}
+		delta.changes = append(delta.changes, levelChange{side, prev})
+	}
+}
+
+// Rounds the given price to a multiple of the book's granularity. Asks are
+// rounded up and bids down, that is, away from the spread.
+func (b *Book) roundPrice(price Price, side bookSide) Price {
+	rem := price % b.granularity
+	if rem < 0 {
+		rem += b.granularity
+	}
+	if rem == 0 {
+		return price
+	}
+
+	price -= rem
+	if side == askSide {
+		price += b.granularity
 	}
+	return price
 }
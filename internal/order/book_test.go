@@ -0,0 +1,318 @@
+package order
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Ensures that past states of a book can be reconstructed within the cache
+// window and that older states are discarded.
+func TestBookAt(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 0, Amount: 1}
+	levels := func(raw ...[]string) []Level {
+		levels, err := prec.ParseLevels(raw)
+		require.NoError(t, err)
+		return levels
+	}
+
+	book := NewBook(prec, 1, 10*time.Second)
+	book.Update(
+		levels([]string{"101", "1.0"}, []string{"102", "2.0"}),
+		levels([]string{"99", "1.0"}, []string{"98", "2.0"}),
+		0, -1,
+	)
+
+	book.Update(levels([]string{"100", "0.5"}), nil, 0, 1_000)
+	book.Update(levels([]string{"100", "0.0"}), levels([]string{"99", "3.0"}), 0, 2_000)
+	book.Update(nil, levels([]string{"99", "0"}), 0, 3_000)
+
+	_, ok := book.At(999)
+	require.False(t, ok)
+
+	view, ok := book.At(1_500)
+	require.True(t, ok)
+	require.EqualValues(t, 100, view.MinAsk())
+	require.EqualValues(t, 99, view.MaxBid())
+	require.Equal(t,
+		[]Level{{99, 10}, {98, 20}},
+		slices.Collect(view.Bids()),
+	)
+
+	view, ok = book.At(2_000)
+	require.True(t, ok)
+	require.EqualValues(t, 101, view.MinAsk())
+	require.Equal(t,
+		[]Level{{99, 30}, {98, 20}},
+		slices.Collect(view.Bids()),
+	)
+
+	// The current state must not be affected by reconstructions.
+	require.EqualValues(t, 101, book.Snapshot().MinAsk())
+	require.EqualValues(t, 98, book.Snapshot().MaxBid())
+
+	// Moving past the cache window discards old states.
+	book.Update(levels([]string{"103", "1.0"}), nil, 0, 11_500)
+
+	_, ok = book.At(1_400)
+	require.False(t, ok)
+
+	view, ok = book.At(2_500)
+	require.True(t, ok)
+	require.EqualValues(t, 99, view.MaxBid())
+	require.Equal(t,
+		[]Level{{101, 10}, {102, 20}},
+		slices.Collect(view.Asks()),
+	)
+}
+
+// Ensures that levels are aggregated exactly and rounded away from the spread.
+func TestBookGranularity(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 2, Amount: 8}
+	book := NewBook(prec, 100, time.Minute) // Granularity of 1.00.
+
+	asks, err := prec.ParseLevels([][]string{
+		{"100.01", "0.10000000"}, {"100.99", "0.20000000"}, {"101.01", "0.3"},
+	})
+	require.NoError(t, err)
+	bids, err := prec.ParseLevels([][]string{
+		{"99.99", "0.10000000"}, {"99.00", "0.20000000"}, {"98.99", "0.3"},
+	})
+	require.NoError(t, err)
+
+	book.Update(asks, bids, 0, 1_000)
+	require.Equal(t,
+		[]Level{{10100, 30_000_000}, {10200, 30_000_000}},
+		slices.Collect(book.Snapshot().Asks()),
+	)
+	require.Equal(t,
+		[]Level{{9900, 30_000_000}, {9800, 30_000_000}},
+		slices.Collect(book.Snapshot().Bids()),
+	)
+
+	// Removing all contributions to an aggregated level (within one update)
+	// removes the level.
+	asks, err = prec.ParseLevels([][]string{{"100.01", "0"}, {"100.99", "0.00000000"}})
+	require.NoError(t, err)
+	book.Update(asks, nil, 0, 2_000)
+	require.Equal(t, "102.00", prec.FormatPrice(book.Snapshot().MinAsk()))
+}
+
+func TestViewDepth(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 0, Amount: 0}
+	book := NewBook(prec, 1, time.Minute)
+	book.Update(
+		[]Level{{101, 1}, {102, 2}, {104, 4}, {110, 10}},
+		[]Level{{99, 1}, {98, 2}, {96, 4}, {90, 10}},
+		0, 1_000,
+	)
+
+	require.Equal(t, []Level{{101, 1}, {102, 2}}, book.Snapshot().TopAsks(2))
+	require.Equal(t, []Level{{99, 1}, {98, 2}, {96, 4}, {90, 10}}, book.Snapshot().TopBids(10))
+	require.Empty(t, book.Snapshot().TopAsks(0))
+
+	require.EqualValues(t, 0, book.Snapshot().AskDepth(100))
+	require.EqualValues(t, 3, book.Snapshot().AskDepth(103))
+	require.EqualValues(t, 7, book.Snapshot().AskDepth(104))
+	require.EqualValues(t, 7, book.Snapshot().BidDepth(96))
+	require.EqualValues(t, 17, book.Snapshot().BidDepth(0))
+	require.EqualValues(t, 17, book.Snapshot().AskDepth(math.MaxInt64))
+	require.EqualValues(t, 17, book.Snapshot().BidDepth(math.MinInt64))
+
+	require.Equal(t,
+		[]Level{{96, 4}, {90, 10}},
+		slices.Collect(book.Snapshot().BidsFrom(97)),
+	)
+	require.Equal(t,
+		[]Level{{104, 4}, {110, 10}},
+		slices.Collect(book.Snapshot().AsksFrom(104)),
+	)
+
+	// Mid is 100; 500 bps cover prices from 95 to 105.
+	mid, ok := book.Snapshot().Mid()
+	require.True(t, ok)
+	require.InDelta(t, 100.0, mid, 1e-9)
+
+	asks, bids := book.Snapshot().LiquidityWithin(500)
+	require.EqualValues(t, 7, asks)
+	require.EqualValues(t, 7, bids)
+
+	// Bands of 100% and more cover all bids; limits beyond the range of
+	// prices are clamped.
+	for _, bps := range []float64{10_000, 1e18, 1e300, math.Inf(1)} {
+		asks, bids = book.Snapshot().LiquidityWithin(bps)
+		require.EqualValues(t, 17, asks)
+		require.EqualValues(t, 17, bids)
+	}
+	asks, bids = book.Snapshot().LiquidityWithin(math.Inf(-1))
+	require.Zero(t, asks)
+	require.Zero(t, bids)
+}
+
+func TestViewExecution(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 2, Amount: 2}
+	book := NewBook(prec, 1, time.Minute)
+	book.Update(
+		[]Level{{100_00, 1_00}, {101_00, 2_00}},
+		[]Level{{99_00, 1_00}, {97_00, 1_00}},
+		0, 1_000,
+	)
+
+	exec := book.Snapshot().Buy(2_00)
+	require.True(t, exec.Complete)
+	require.EqualValues(t, 2_00, exec.Filled)
+	require.InDelta(t, 201.0, exec.Notional, 1e-9)
+	require.InDelta(t, 100.5, exec.AvgPrice, 1e-9)
+	require.EqualValues(t, 100_00, exec.BestPrice)
+	require.EqualValues(t, 101_00, exec.WorstPrice)
+	require.InDelta(t, 50.0, exec.Slippage, 1e-9)
+
+	exec = book.Snapshot().Sell(3_00)
+	require.False(t, exec.Complete)
+	require.EqualValues(t, 2_00, exec.Filled)
+	require.InDelta(t, 98.0, exec.AvgPrice, 1e-9)
+
+	exec = book.Snapshot().BuyNotional(150)
+	require.True(t, exec.Complete)
+	require.EqualValues(t, 1_49, exec.Filled)
+	require.InDelta(t, 149.49, exec.Notional, 1e-9)
+	require.EqualValues(t, 101_00, exec.WorstPrice)
+
+	// Once the notional limits a level, worse levels are not used even if
+	// their lots are cheaper.
+	exec = book.Snapshot().SellNotional(1.96)
+	require.True(t, exec.Complete)
+	require.EqualValues(t, 1, exec.Filled)
+	require.EqualValues(t, 99_00, exec.WorstPrice)
+	require.Zero(t, exec.Slippage)
+
+	exec = book.Snapshot().SellNotional(1_000)
+	require.False(t, exec.Complete)
+	require.InDelta(t, 196.0, exec.Notional, 1e-9)
+}
+
+// Ensures that readers can access a book while it is updated. Intended to be
+// run with the race detector.
+func TestBookConcurrentReads(t *testing.T) {
+	t.Parallel()
+
+	book := NewBook(Precision{}, 1, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ts := range int64(1_000) {
+			price := Price(100 + ts%10)
+			book.Update([]Level{{price, Amount(ts%3 + 1)}}, []Level{{price - 20, Amount(ts % 2)}}, 0, ts)
+		}
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+
+		snapshot := book.Snapshot()
+		asks, _ := snapshot.Len()
+		require.LessOrEqual(t, asks, 10)
+
+		view, ok := book.At(snapshot.Timestamp() - 5)
+		if ok {
+			require.LessOrEqual(t, view.Timestamp(), snapshot.Timestamp())
+			require.NotZero(t, view.MinAsk())
+		}
+	}
+}
+
+func TestBookMarshalBinary(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 2, Amount: 8}
+	book := NewBook(prec, 100, time.Minute)
+	book.Update(
+		[]Level{{100_00, 1}, {105_00, 123_456_789}, {1_000_000_00, 7}},
+		[]Level{{99_00, 42}, {1_00, 1}},
+		12345, 1_700_000_000_000,
+	)
+
+	data, err := book.MarshalBinary()
+	require.NoError(t, err)
+
+	restored, err := UnmarshalBook(data, time.Minute)
+	require.NoError(t, err)
+
+	require.Equal(t, prec, restored.Snapshot().Precision())
+	require.EqualValues(t, 12345, restored.UpdateID())
+	require.Equal(t, book.Snapshot().Timestamp(), restored.Snapshot().Timestamp())
+	require.Equal(t, slices.Collect(book.Snapshot().Asks()), slices.Collect(restored.Snapshot().Asks()))
+	require.Equal(t, slices.Collect(book.Snapshot().Bids()), slices.Collect(restored.Snapshot().Bids()))
+
+	// Restored books keep aggregating with the same granularity.
+	restored.Update([]Level{{100_01, 5}}, nil, 12346, 1_700_000_000_100)
+	require.Equal(t, []Level{{100_00, 1}, {101_00, 5}}, restored.Snapshot().TopAsks(2))
+
+	_, err = UnmarshalBook(data[:len(data)-1], time.Minute)
+	require.Error(t, err)
+
+	data[4] = bookVersion + 1
+	_, err = UnmarshalBook(data, time.Minute)
+	require.Error(t, err)
+}
+
+// Ensures that corrupt books are rejected instead of restored.
+func TestUnmarshalBookCorrupt(t *testing.T) {
+	t.Parallel()
+
+	prec := Precision{Price: 2, Amount: 8}
+	for _, tc := range []struct {
+		name        string
+		precision   Precision
+		granularity int64
+		asks, bids  []Level
+		valid       bool
+	}{
+		{"valid", prec, 1, []Level{{101_00, 1}, {102_00, 1}}, []Level{{98_00, 1}, {99_00, 1}}, true},
+		{"precision", Precision{Price: maxDecimals + 1, Amount: 8}, 1, []Level{{101_00, 1}}, nil, false},
+		{"granularity", prec, 0, []Level{{101_00, 1}}, nil, false},
+		{"unsorted", prec, 1, []Level{{102_00, 1}, {101_00, 1}}, nil, false},
+		{"duplicate", prec, 1, nil, []Level{{99_00, 1}, {99_00, 2}}, false},
+		{"zero amount", prec, 1, []Level{{101_00, 0}}, nil, false},
+		{"negative amount", prec, 1, nil, []Level{{99_00, -1}}, false},
+		{"crossed", prec, 1, []Level{{100_00, 1}}, []Level{{100_00, 1}}, false},
+	} {
+		data := append([]byte(bookMagic), bookVersion, tc.precision.Price, tc.precision.Amount)
+		data = binary.AppendVarint(data, tc.granularity)
+		data = binary.AppendVarint(data, 1) // update ID
+		data = binary.AppendVarint(data, 1_700_000_000_000)
+		for _, levels := range [][]Level{tc.asks, tc.bids} {
+			data = binary.AppendUvarint(data, uint64(len(levels)))
+			prevPrice := Price(0)
+			for _, level := range levels {
+				data = binary.AppendVarint(data, int64(level.Price-prevPrice))
+				data = binary.AppendVarint(data, int64(level.Amount))
+				prevPrice = level.Price
+			}
+		}
+
+		_, err := UnmarshalBook(data, time.Minute)
+		if tc.valid {
+			require.NoError(t, err, tc.name)
+		} else {
+			require.Error(t, err, tc.name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package order
+
+import (
+	"math"
+
+	"github.com/google/btree"
+)
+
+// A book which contributes to a consolidated book.
+type Source struct {
+	// The exchange and pair under which the book is registered.
+	Exchange string
+	Pair     string
+
+	View View
+
+	// Converts prices into the quote currency of the consolidated book, e.g.
+	// the price of USD in USDT for a BTC-USD book within a BTC-USDT one.
+	Rate float64
+
+	// The taker fee as fraction, e.g. 0.001 for 10 bps. Ask prices are raised
+	// and bid prices are lowered by it, which yields the prices a taker
+	// effectively pays or receives.
+	Fee float64
+}
+
+// The part of a consolidated level provided by a single source.
+type SourceLevel struct {
+	Exchange string
+	Pair     string
+
+	// The amount of the source at the consolidated level.
+	Amount Amount
+
+	// The price of the source's level before adjustments, in its precision.
+	Original Price
+}
+
+// A book which merges the levels of several books of the same instrument.
+// Its embedded View provides the same queries as a book's snapshot; the levels
+// of each price can be attributed to their sources.
+type Consolidated struct {
+	View
+
+	asks map[Price][]SourceLevel
+	bids map[Price][]SourceLevel
+}
+
+// Merges the given books. Prices are converted with the rate of each source
+// and adjusted by its fee; asks are rounded up and bids down. The precision
+// of the consolidated book is the highest precision of the sources, which
+// keeps unadjusted levels exact. Its timestamp is that of the most recent
+// source.
+func Consolidate(sources ...Source) Consolidated {
+	c := Consolidated{
+		View: View{
+			timestamp: -1,
+			asks:      btree.NewG(64, levelLess),
+			bids:      btree.NewG(64, levelLess),
+		},
+		asks: map[Price][]SourceLevel{},
+		bids: map[Price][]SourceLevel{},
+	}
+
+	for _, source := range sources {
+		c.precision.Price = max(c.precision.Price, source.View.precision.Price)
+		c.precision.Amount = max(c.precision.Amount, source.View.precision.Amount)
+		c.timestamp = max(c.timestamp, source.View.timestamp)
+	}
+
+	for _, source := range sources {
+		c.merge(source, askSide)
+		c.merge(source, bidSide)
+	}
+
+	return c
+}
+
+// Adds the levels of one side of the given source.
+func (c *Consolidated) merge(source Source, side bookSide) {
+	tree, attribution, levels, factor := c.View.asks, c.asks, source.View.Asks(), 1+source.Fee
+	if side == bidSide {
+		tree, attribution, levels, factor = c.View.bids, c.bids, source.View.Bids(), 1-source.Fee
+	}
+	factor *= source.Rate
+
+	priceShift := int(c.precision.Price - source.View.precision.Price)
+	amountScale := Amount(math.Pow10(int(c.precision.Amount - source.View.precision.Amount)))
+
+	for level := range levels {
+		price := scalePrice(level.Price, priceShift, factor, side)
+		amount := level.Amount * amountScale
+
+		entry, _ := tree.Get(Level{Price: price})
+		tree.ReplaceOrInsert(Level{Price: price, Amount: entry.Amount + amount})
+
+		attribution[price] = append(attribution[price], SourceLevel{
+			Exchange: source.Exchange,
+			Pair:     source.Pair,
+			Amount:   amount,
+			Original: level.Price,
+		})
+	}
+}
+
+// Converts a price to `shift` more decimals and multiplies it by `factor`.
+// Inexact results are rounded against the taker: asks up and bids down.
+func scalePrice(price Price, shift int, factor float64, side bookSide) Price {
+	if factor == 1 {
+		return price * Price(math.Pow10(shift))
+	}
+
+	scaled := float64(price) * math.Pow10(shift) * factor
+
+	// Tolerate floating-point errors of prices which are exact.
+	if rounded := math.Round(scaled); math.Abs(scaled-rounded) < 1e-6 {
+		return Price(rounded)
+	}
+
+	if side == askSide {
+		return Price(math.Ceil(scaled))
+	}
+	return Price(math.Floor(scaled))
+}
+
+// Returns the sources of the ask level at the given price.
+func (c Consolidated) AskSources(price Price) []SourceLevel {
+	return c.asks[price]
+}
+
+// Returns the sources of the bid level at the given price.
+func (c Consolidated) BidSources(price Price) []SourceLevel {
+	return c.bids[price]
+}
@@ -0,0 +1,67 @@
+package order
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Books of different precisions must be merged exactly, and each level must
+// remain attributable to its sources.
+func TestConsolidate(t *testing.T) {
+	t.Parallel()
+
+	binance := NewBook(Precision{Price: 2, Amount: 8}, 1, time.Minute)
+	binance.Update(
+		[]Level{{10010, 100_000_000}, {10020, 200_000_000}},
+		[]Level{{9990, 100_000_000}},
+		0, -1,
+	)
+	kraken := NewBook(Precision{Price: 1, Amount: 4}, 1, time.Minute)
+	kraken.Update([]Level{{1001, 5_000}}, []Level{{1000, 2_500}}, 0, -1)
+
+	c := Consolidate(
+		Source{Exchange: "binance", Pair: "btc-usdt", View: binance.Snapshot(), Rate: 1},
+		Source{Exchange: "kraken", Pair: "btc-usd", View: kraken.Snapshot(), Rate: 1},
+	)
+
+	require.Equal(t, Precision{Price: 2, Amount: 8}, c.Precision())
+	require.Equal(t,
+		[]Level{{10010, 150_000_000}, {10020, 200_000_000}},
+		slices.Collect(c.Asks()),
+	)
+	require.Equal(t,
+		[]Level{{10000, 25_000_000}, {9990, 100_000_000}},
+		slices.Collect(c.Bids()),
+	)
+	require.EqualValues(t, 350_000_000, c.AskDepth(10020))
+	require.Equal(t,
+		[]SourceLevel{
+			{Exchange: "binance", Pair: "btc-usdt", Amount: 100_000_000, Original: 10010},
+			{Exchange: "kraken", Pair: "btc-usd", Amount: 50_000_000, Original: 1001},
+		},
+		c.AskSources(10010),
+	)
+	require.Len(t, c.BidSources(10000), 1)
+	require.Empty(t, c.BidSources(9995))
+
+	// Fees raise asks and lower bids; rates convert prices.
+	c = Consolidate(
+		Source{Exchange: "binance", Pair: "btc-usdt", View: binance.Snapshot(), Rate: 1, Fee: 0.001},
+		Source{Exchange: "kraken", Pair: "btc-usd", View: kraken.Snapshot(), Rate: 0.5},
+	)
+
+	bestAsk, ok := c.BestAsk()
+	require.True(t, ok)
+	require.Equal(t, Level{5005, 50_000_000}, bestAsk)
+	require.Equal(t,
+		[]Level{{5005, 50_000_000}, {10021, 100_000_000}, {10031, 200_000_000}},
+		slices.Collect(c.Asks()),
+	)
+	require.Equal(t,
+		[]Level{{9980, 100_000_000}, {5000, 25_000_000}},
+		slices.Collect(c.Bids()),
+	)
+}
@@ -0,0 +1,209 @@
+package order
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/risingwavelabs/eris"
+)
+
+// Prices and amounts are fixed-point decimals, that is, integers which count
+// multiples of 10^-d. The number of decimals `d` is not part of the value;
+// it is defined per instrument by its Precision.
+type (
+	Price  int64
+	Amount int64
+)
+
+// The number of decimals used for prices and amounts of an instrument. They
+// usually match the instrument's tick and lot size.
+type Precision struct {
+	Price  uint8 `yaml:"price"`
+	Amount uint8 `yaml:"amount"`
+}
+
+// The largest supported number of decimals.
+const maxDecimals = 18
+
+// The largest supported absolute exponent of decimals in scientific notation.
+// Larger ones either overflow an int64 (19 digits) or only yield zeros.
+const maxExponent = maxDecimals + 19
+
+func (p Precision) ParsePrice(s string) (Price, error) {
+	v, err := parseDecimal(s, p.Price)
+	return Price(v), err
+}
+
+func (p Precision) ParseAmount(s string) (Amount, error) {
+	v, err := parseDecimal(s, p.Amount)
+	return Amount(v), err
+}
+
+// Parses levels as send by most exchanges: a list of price-amount pairs.
+func (p Precision) ParseLevels(raw [][]string) ([]Level, error) {
+	levels := make([]Level, len(raw))
+	for i, rawLevel := range raw {
+		if len(rawLevel) < 2 {
+			return nil, eris.Errorf("invalid level: %q", rawLevel)
+		}
+
+		var err error
+		levels[i].Price, err = p.ParsePrice(rawLevel[0])
+		if err != nil {
+			return nil, err
+		}
+
+		levels[i].Amount, err = p.ParseAmount(rawLevel[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return levels, nil
+}
+
+func (p Precision) FormatPrice(x Price) string {
+	return formatDecimal(int64(x), p.Price)
+}
+
+func (p Precision) FormatAmount(x Amount) string {
+	return formatDecimal(int64(x), p.Amount)
+}
+
+// Converts the given price into a float. The result is not necessarily exact.
+func (p Precision) PriceFloat(x Price) float64 {
+	return float64(x) / math.Pow10(int(p.Price))
+}
+
+// Converts the given amount into a float. The result is not necessarily exact.
+func (p Precision) AmountFloat(x Amount) float64 {
+	return float64(x) / math.Pow10(int(p.Amount))
+}
+
+// Parses a decimal number with as many decimals as needed to represent it
+// exactly. Trailing zeros after the decimal point count as decimals.
+func ParseDecimal(s string) (int64, uint8, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		exponent, _ = strconv.Atoi(s[i+1:]) // Validated by `parseDecimal()`.
+	}
+
+	_, fracPart, _ := strings.Cut(mantissa, ".")
+	decimals := uint8(min(max(len(fracPart)-exponent, 0), maxDecimals))
+
+	value, err := parseDecimal(s, decimals)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return value, decimals, nil
+}
+
+// Parses a decimal number (e.g. "-12.340" or "1.5e-3") into an integer which
+// counts multiples of 10^-decimals. It fails if the number cannot be
+// represented exactly, i.e., if it has non-zero digits beyond the given
+// number of decimals.
+func parseDecimal(s string, decimals uint8) (int64, error) {
+	if decimals > maxDecimals {
+		return 0, eris.Errorf("unsupported number of decimals: %d", decimals)
+	}
+
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		exponent, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, eris.Wrapf(err, "invalid exponent in decimal: '%s'", s)
+		}
+		if exponent < -maxExponent || exponent > maxExponent {
+			// Zeros are valid with any exponent.
+			if strings.Trim(mantissa, "+-.0") != "" {
+				return 0, eris.Errorf("exponent out of range in decimal: '%s'", s)
+			}
+			exponent = 0
+		}
+	}
+
+	negative := false
+	if len(mantissa) > 0 && (mantissa[0] == '-' || mantissa[0] == '+') {
+		negative = mantissa[0] == '-'
+		mantissa = mantissa[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(mantissa, ".")
+	if len(intPart)+len(fracPart) == 0 {
+		return 0, eris.Errorf("invalid decimal: '%s'", s)
+	}
+
+	// The position of the decimal point within `digits` after applying the
+	// exponent and scaling by 10^decimals.
+	digits := intPart + fracPart
+	point := len(intPart) + exponent + int(decimals)
+
+	var value int64
+	for i, char := range []byte(digits) {
+		if char < '0' || char > '9' {
+			return 0, eris.Errorf("invalid decimal: '%s'", s)
+		}
+
+		digit := int64(char - '0')
+		if i >= point {
+			if digit != 0 {
+				return 0, eris.Errorf("decimal '%s' exceeds precision of %d decimals", s, decimals)
+			}
+			continue
+		}
+
+		if value > (math.MaxInt64-digit)/10 {
+			return 0, eris.Errorf("decimal out of range: '%s'", s)
+		}
+		value = 10*value + digit
+	}
+
+	// Pad missing digits before the decimal point.
+	for range point - len(digits) {
+		if value > math.MaxInt64/10 {
+			return 0, eris.Errorf("decimal out of range: '%s'", s)
+		}
+		value *= 10
+	}
+
+	if negative {
+		value = -value
+	}
+
+	return value, nil
+}
+
+// Formats an integer counting multiples of 10^-decimals as decimal number
+// with exactly `decimals` digits after the decimal point.
+func formatDecimal(value int64, decimals uint8) string {
+	digits := strconv.FormatUint(absInt64(value), 10)
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	builder := strings.Builder{}
+	if value < 0 {
+		builder.WriteByte('-')
+	}
+
+	point := len(digits) - int(decimals)
+	builder.WriteString(digits[:point])
+	if decimals > 0 {
+		builder.WriteByte('.')
+		builder.WriteString(digits[point:])
+	}
+
+	return builder.String()
+}
+
+func absInt64(x int64) uint64 {
+	if x < 0 {
+		return uint64(-(x + 1)) + 1
+	}
+	return uint64(x)
+}
@@ -0,0 +1,91 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input    string
+		decimals uint8
+		expected int64
+	}{
+		{"0", 2, 0},
+		{"12.34", 2, 1234},
+		{"12.3", 2, 1230},
+		{"12.34000000", 2, 1234},
+		{"-0.01", 2, -1},
+		{".5", 1, 5},
+		{"7.", 0, 7},
+		{"1.5e-3", 4, 15},
+		{"2E2", 0, 200},
+		{"96123.45000000", 8, 9_612_345_000_000},
+		{"0e999999999", 2, 0},
+		{"-0.00e-999999999", 2, 0},
+	} {
+		actual, err := parseDecimal(tc.input, tc.decimals)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, actual, tc.input)
+	}
+
+	for _, tc := range []struct {
+		input    string
+		decimals uint8
+	}{
+		{"", 2},
+		{"-", 2},
+		{"1.2.3", 2},
+		{"abc", 2},
+		{"12.345", 2},
+		{"1e-3", 2},
+		{"99999999999999999999", 0},
+		{".e999999999", 2},
+		{"1e-999999999", 2},
+	} {
+		_, err := parseDecimal(tc.input, tc.decimals)
+		require.Error(t, err, tc.input)
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value    int64
+		decimals uint8
+		expected string
+	}{
+		{0, 0, "0"},
+		{0, 2, "0.00"},
+		{1234, 2, "12.34"},
+		{5, 3, "0.005"},
+		{-5, 3, "-0.005"},
+		{1234, 0, "1234"},
+	} {
+		require.Equal(t, tc.expected, formatDecimal(tc.value, tc.decimals))
+	}
+}
+
+func TestParseDecimalAuto(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		input    string
+		value    int64
+		decimals uint8
+	}{
+		{"12", 12, 0},
+		{"12.340", 12340, 3},
+		{"1.5e-3", 15, 4},
+		{"1.5e3", 1500, 0},
+	} {
+		value, decimals, err := ParseDecimal(tc.input)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.value, value, tc.input)
+		require.Equal(t, tc.decimals, decimals, tc.input)
+	}
+}
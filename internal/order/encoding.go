@@ -0,0 +1,143 @@
+package order
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/google/btree"
+	"github.com/risingwavelabs/eris"
+)
+
+// The binary format of a book is:
+//
+//	magic        4 bytes "FOBK"
+//	version      1 byte
+//	precision    2 bytes (price and amount decimals)
+//	granularity  varint
+//	update ID    varint
+//	timestamp    varint
+//	asks         uvarint count, followed by levels
+//	bids         uvarint count, followed by levels
+//
+// Levels are sorted by ascending price. Each level consists of the difference
+// to the previous level's price (the first one to zero) as varint and the
+// amount as varint.
+const (
+	bookMagic   = "FOBK"
+	bookVersion = 1
+)
+
+// Encodes the latest state of the book (without its cached history) into a
+// compact binary format. Safe for concurrent use.
+func (b *Book) MarshalBinary() ([]byte, error) {
+	state := b.published.Load()
+	asks, bids := state.Len()
+
+	buf := make([]byte, 0, len(bookMagic)+3+3*binary.MaxVarintLen64+4*(asks+bids))
+	buf = append(buf, bookMagic...)
+	buf = append(buf, bookVersion, state.precision.Price, state.precision.Amount)
+	buf = binary.AppendVarint(buf, int64(state.granularity))
+	buf = binary.AppendVarint(buf, state.updateID)
+	buf = binary.AppendVarint(buf, state.timestamp)
+
+	for _, tree := range []*btree.BTreeG[Level]{state.asks, state.bids} {
+		buf = binary.AppendUvarint(buf, uint64(tree.Len()))
+
+		prevPrice := Price(0)
+		tree.Ascend(func(level Level) bool {
+			buf = binary.AppendVarint(buf, int64(level.Price-prevPrice))
+			buf = binary.AppendVarint(buf, int64(level.Amount))
+			prevPrice = level.Price
+			return true
+		})
+	}
+
+	return buf, nil
+}
+
+// Decodes a book encoded with `MarshalBinary()`. The restored book has no
+// cached history; it retains new states for the duration of `cacheWindow`.
+func UnmarshalBook(data []byte, cacheWindow time.Duration) (*Book, error) {
+	reader := bytes.NewReader(data)
+
+	header := make([]byte, len(bookMagic)+3)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return nil, eris.Wrap(err, "failed to read header")
+	}
+
+	if string(header[:len(bookMagic)]) != bookMagic {
+		return nil, eris.New("invalid header: not an order book")
+	}
+	if version := header[len(bookMagic)]; version != bookVersion {
+		return nil, eris.Errorf("unsupported version: %d", version)
+	}
+
+	precision := Precision{
+		Price:  header[len(bookMagic)+1],
+		Amount: header[len(bookMagic)+2],
+	}
+	if precision.Price > maxDecimals || precision.Amount > maxDecimals {
+		return nil, eris.Errorf("invalid precision: %d/%d", precision.Price, precision.Amount)
+	}
+
+	var granularity, updateID, timestamp int64
+	for _, dst := range []*int64{&granularity, &updateID, &timestamp} {
+		*dst, err = binary.ReadVarint(reader)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to read header")
+		}
+	}
+	if granularity <= 0 {
+		return nil, eris.Errorf("invalid granularity: %d", granularity)
+	}
+
+	book := NewBook(precision, Price(granularity), cacheWindow)
+	book.updateID = updateID
+	book.cur.timestamp = timestamp
+
+	for _, tree := range []*btree.BTreeG[Level]{book.cur.asks, book.cur.bids} {
+		count, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, eris.Wrap(err, "failed to read number of levels")
+		}
+
+		level := Level{}
+		for i := range count {
+			priceDiff, err := binary.ReadVarint(reader)
+			if err != nil {
+				return nil, eris.Wrap(err, "failed to read level")
+			}
+			amount, err := binary.ReadVarint(reader)
+			if err != nil {
+				return nil, eris.Wrap(err, "failed to read level")
+			}
+
+			price := level.Price + Price(priceDiff)
+			if i > 0 && (priceDiff <= 0 || price < level.Price) {
+				return nil, eris.New("levels are not sorted by ascending price")
+			}
+			if amount <= 0 {
+				return nil, eris.Errorf("invalid amount at price %d: %d", price, amount)
+			}
+
+			level = Level{price, Amount(amount)}
+			tree.ReplaceOrInsert(level)
+		}
+	}
+
+	if reader.Len() > 0 {
+		return nil, eris.Errorf("unexpected %d bytes after book", reader.Len())
+	}
+
+	bestAsk, okAsk := book.cur.BestAsk()
+	bestBid, okBid := book.cur.BestBid()
+	if okAsk && okBid && bestBid.Price >= bestAsk.Price {
+		return nil, eris.Errorf("crossed book: best bid %d, best ask %d", bestBid.Price, bestAsk.Price)
+	}
+
+	book.publish()
+	return book, nil
+}
@@ -0,0 +1,108 @@
+package order
+
+import (
+	"iter"
+	"math"
+)
+
+// The estimated outcome of a market order which is executed against all
+// levels of one side of a book (walking the book).
+type Execution struct {
+	// The base amount which was filled.
+	Filled Amount
+
+	// The quote amount paid (buy) or received (sell).
+	Notional float64
+
+	// The average price of all fills in units of the quote currency.
+	AvgPrice float64
+
+	// The prices of the first and last level used.
+	BestPrice  Price
+	WorstPrice Price
+
+	// The difference between the average price and the best price in basis
+	// points. It is never negative.
+	Slippage float64
+
+	// False if the book did not provide enough liquidity to fill the order.
+	Complete bool
+}
+
+// Estimates the execution of a market buy order of the given base amount.
+func (v View) Buy(amount Amount) Execution {
+	return v.walk(v.Asks(), amount, math.Inf(1))
+}
+
+// Estimates the execution of a market sell order of the given base amount.
+func (v View) Sell(amount Amount) Execution {
+	return v.walk(v.Bids(), amount, math.Inf(1))
+}
+
+// Estimates the execution of a market buy order which spends (up to) the
+// given quote amount.
+func (v View) BuyNotional(notional float64) Execution {
+	return v.walk(v.Asks(), math.MaxInt64, notional)
+}
+
+// Estimates the execution of a market sell order which receives (up to) the
+// given quote amount.
+func (v View) SellNotional(notional float64) Execution {
+	return v.walk(v.Bids(), math.MaxInt64, notional)
+}
+
+// Consumes the given levels (sorted from best to worst) until either the
+// amount or the notional limit is reached.
+func (v View) walk(levels iter.Seq[Level], maxAmount Amount, maxNotional float64) Execution {
+	exec := Execution{}
+	amountScale := math.Pow10(int(v.precision.Amount))
+
+	for level := range levels {
+		if exec.Filled >= maxAmount || exec.Notional >= maxNotional {
+			break
+		}
+
+		price := v.precision.PriceFloat(level.Price)
+		take := min(level.Amount, maxAmount-exec.Filled)
+		capped := false
+		if notional := price * v.precision.AmountFloat(take); exec.Notional+notional > maxNotional {
+			take = Amount(math.Floor((maxNotional - exec.Notional) / price * amountScale))
+			capped = true
+		}
+		if take <= 0 {
+			break
+		}
+
+		if exec.Filled == 0 {
+			exec.BestPrice = level.Price
+		}
+		exec.WorstPrice = level.Price
+		exec.Filled += take
+		exec.Notional += price * v.precision.AmountFloat(take)
+
+		// The remaining notional does not buy another lot at this level, so
+		// neither at worse ones.
+		if capped {
+			break
+		}
+	}
+
+	if exec.Filled == 0 {
+		return exec
+	}
+
+	exec.AvgPrice = exec.Notional / v.precision.AmountFloat(exec.Filled)
+
+	bestPrice := v.precision.PriceFloat(exec.BestPrice)
+	exec.Slippage = math.Abs(exec.AvgPrice-bestPrice) / bestPrice * 10_000
+
+	if maxNotional < math.Inf(1) {
+		// Remaining notional too small for another lot counts as complete.
+		nextLot := v.precision.PriceFloat(exec.WorstPrice) / amountScale
+		exec.Complete = exec.Notional+nextLot > maxNotional
+	} else {
+		exec.Complete = exec.Filled >= maxAmount
+	}
+
+	return exec
+}
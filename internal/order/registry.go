@@ -0,0 +1,63 @@
+package order
+
+import (
+	"sync"
+)
+
+// Books which are currently maintained by the process, keyed by exchange and
+// pair. It allows other components to read them.
+var registry = struct {
+	sync.RWMutex
+	books map[registryKey]*Book
+}{
+	books: map[registryKey]*Book{},
+}
+
+type registryKey struct {
+	exchange string
+	pair     string
+}
+
+// Makes the given book available via `Lookup()`. It replaces any book
+// previously registered for the same exchange and pair.
+func Register(exchange, pair string, book *Book) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.books[registryKey{exchange, pair}] = book
+}
+
+// Removes the given book from the registry. It does nothing if a different
+// book is registered for the same exchange and pair.
+func Unregister(exchange, pair string, book *Book) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	key := registryKey{exchange, pair}
+	if registry.books[key] == book {
+		delete(registry.books, key)
+	}
+}
+
+// Returns the book registered for the given exchange and pair.
+func Lookup(exchange, pair string) (*Book, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	book, ok := registry.books[registryKey{exchange, pair}]
+	return book, ok
+}
+
+// Returns the books registered for the given pair, keyed by exchange.
+func LookupPair(pair string) map[string]*Book {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	books := map[string]*Book{}
+	for key, book := range registry.books {
+		if key.pair == pair {
+			books[key.exchange] = book
+		}
+	}
+	return books
+}
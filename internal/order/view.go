@@ -0,0 +1,181 @@
+package order
+
+import (
+	"iter"
+	"math"
+
+	"github.com/google/btree"
+)
+
+// A single price level of an order book.
+type Level struct {
+	Price  Price
+	Amount Amount
+}
+
+func levelLess(a, b Level) bool {
+	return a.Price < b.Price
+}
+
+// A read-only state of an order book.
+type View struct {
+	precision Precision
+
+	// Unix time (in ms) of the latest update included in the view.
+	timestamp int64
+
+	asks *btree.BTreeG[Level]
+	bids *btree.BTreeG[Level]
+}
+
+func (v View) Precision() Precision {
+	return v.precision
+}
+
+func (v View) Timestamp() int64 {
+	return v.timestamp
+}
+
+// Returns the number of ask and bid levels.
+func (v View) Len() (asks, bids int) {
+	return v.asks.Len(), v.bids.Len()
+}
+
+// Returns the ask level with the lowest price. The second return value is
+// false if there are no asks.
+func (v View) BestAsk() (Level, bool) {
+	return v.asks.Min()
+}
+
+// Returns the bid level with the highest price. The second return value is
+// false if there are no bids.
+func (v View) BestBid() (Level, bool) {
+	return v.bids.Max()
+}
+
+func (v View) MinAsk() Price {
+	minEntry, _ := v.asks.Min()
+	return minEntry.Price
+}
+
+func (v View) MaxBid() Price {
+	maxEntry, _ := v.bids.Max()
+	return maxEntry.Price
+}
+
+// Iterates over all ask levels, starting with the lowest price.
+func (v View) Asks() iter.Seq[Level] {
+	return func(yield func(Level) bool) {
+		v.asks.Ascend(yield)
+	}
+}
+
+// Iterates over all bid levels, starting with the highest price.
+func (v View) Bids() iter.Seq[Level] {
+	return func(yield func(Level) bool) {
+		v.bids.Descend(yield)
+	}
+}
+
+// Iterates over all ask levels with a price of at least `price`, starting with
+// the lowest price.
+func (v View) AsksFrom(price Price) iter.Seq[Level] {
+	return func(yield func(Level) bool) {
+		v.asks.AscendGreaterOrEqual(Level{Price: price}, yield)
+	}
+}
+
+// Iterates over all bid levels with a price of at most `price`, starting with
+// the highest price.
+func (v View) BidsFrom(price Price) iter.Seq[Level] {
+	return func(yield func(Level) bool) {
+		v.bids.DescendLessOrEqual(Level{Price: price}, yield)
+	}
+}
+
+// Returns the (up to) `n` ask levels with the lowest prices.
+func (v View) TopAsks(n int) []Level {
+	return topLevels(v.Asks(), min(n, v.asks.Len()))
+}
+
+// Returns the (up to) `n` bid levels with the highest prices.
+func (v View) TopBids(n int) []Level {
+	return topLevels(v.Bids(), min(n, v.bids.Len()))
+}
+
+func topLevels(levels iter.Seq[Level], n int) []Level {
+	top := make([]Level, 0, max(n, 0))
+	for level := range levels {
+		if len(top) >= n {
+			break
+		}
+		top = append(top, level)
+	}
+	return top
+}
+
+// Returns the total amount of all asks with a price of at most `price`.
+func (v View) AskDepth(price Price) Amount {
+	var depth Amount
+	v.asks.Ascend(func(level Level) bool {
+		if level.Price > price {
+			return false
+		}
+		depth += level.Amount
+		return true
+	})
+	return depth
+}
+
+// Returns the total amount of all bids with a price of at least `price`.
+func (v View) BidDepth(price Price) Amount {
+	var depth Amount
+	v.bids.Descend(func(level Level) bool {
+		if level.Price < price {
+			return false
+		}
+		depth += level.Amount
+		return true
+	})
+	return depth
+}
+
+// Returns the price between the best ask and the best bid in units of the
+// book's prices, i.e., multiples of 10^-precision (see `Precision.PriceFloat`
+// for the decimal value). The second return value is false if either side is
+// empty.
+func (v View) Mid() (float64, bool) {
+	bestAsk, okAsk := v.BestAsk()
+	bestBid, okBid := v.BestBid()
+	if !okAsk || !okBid {
+		return 0, false
+	}
+
+	return (float64(bestAsk.Price) + float64(bestBid.Price)) / 2, true
+}
+
+// Returns the total amounts of asks and bids whose prices are within the given
+// number of basis points from the mid price. Both are zero if either side of
+// the book is empty.
+func (v View) LiquidityWithin(bps float64) (asks, bids Amount) {
+	mid, ok := v.Mid()
+	if !ok || math.IsNaN(bps) {
+		return 0, 0
+	}
+
+	maxAsk := clampPrice(math.Floor(mid * (1 + bps/10_000)))
+	minBid := clampPrice(math.Ceil(mid * (1 - bps/10_000)))
+
+	return v.AskDepth(maxAsk), v.BidDepth(minBid)
+}
+
+// Converts the given price, saturating at the limits of the type.
+func clampPrice(x float64) Price {
+	switch {
+	case x >= math.MaxInt64:
+		return math.MaxInt64
+	case x <= math.MinInt64:
+		return math.MinInt64
+	}
+	return Price(x)
+}
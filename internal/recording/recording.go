@@ -0,0 +1,277 @@
+package recording
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/risingwavelabs/eris"
+)
+
+// Recordings are gzip-compressed, append-only files. Each file is a sequence
+// of records with the following format:
+//
+//	kind          1 byte
+//	receive time  varint (Unix time in ms)
+//	length        uvarint
+//	data          `length` bytes
+
+// The kind of a record. It is up to the user of a recording to define them.
+type Kind byte
+
+type Record struct {
+	Kind Kind
+	Time time.Time
+	Data []byte
+}
+
+const (
+	// File extension of recordings.
+	fileExt = ".rec.gz"
+
+	// How often buffered records are flushed to disk.
+	flushInterval = time.Second
+
+	// The largest data of a single record. Larger lengths indicate a corrupt
+	// file. Full order books of Binance take less than 1 MiB.
+	maxRecordSize = 64 << 20
+)
+
+// Writes records into files within a directory. A new file is started once
+// the current file is older than the rotation interval. Safe for concurrent
+// use. A nil writer discards all records.
+type Writer struct {
+	dir            string
+	prefix         string
+	rotateInterval time.Duration
+
+	lock      sync.Mutex
+	file      *os.File
+	gzWriter  *gzip.Writer
+	bufWriter *bufio.Writer
+	opened    time.Time
+	flushed   time.Time
+}
+
+// Creates a writer for files named `<prefix>-<start time>.rec.gz` in the given
+// directory.
+func NewWriter(dir, prefix string, rotateInterval time.Duration) (*Writer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, eris.Wrapf(err, "failed to create directory '%s'", dir)
+	}
+
+	return &Writer{
+		dir:            dir,
+		prefix:         prefix,
+		rotateInterval: rotateInterval,
+	}, nil
+}
+
+func (w *Writer) Write(rec Record) error {
+	if w == nil {
+		return nil
+	}
+
+	if len(rec.Data) > maxRecordSize {
+		return eris.Errorf("record of %d bytes exceeds maximum size", len(rec.Data))
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file != nil && rec.Time.Sub(w.opened) >= w.rotateInterval {
+		err := w.closeFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.file == nil {
+		err := w.openFile(rec.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	header := make([]byte, 0, 1+2*binary.MaxVarintLen64)
+	header = append(header, byte(rec.Kind))
+	header = binary.AppendVarint(header, rec.Time.UnixMilli())
+	header = binary.AppendUvarint(header, uint64(len(rec.Data)))
+
+	_, err := w.bufWriter.Write(header)
+	if err == nil {
+		_, err = w.bufWriter.Write(rec.Data)
+	}
+	if err != nil {
+		return eris.Wrapf(err, "failed to write record to '%s'", w.file.Name())
+	}
+
+	if time.Since(w.flushed) >= flushInterval {
+		err = w.flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flushes all buffered records and closes the current file.
+func (w *Writer) Close() error {
+	if w == nil {
+		return nil
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.closeFile()
+}
+
+func (w *Writer) openFile(start time.Time) error {
+	name := fmt.Sprintf("%s-%s%s", w.prefix, start.UTC().Format("20060102-150405.000"), fileExt)
+
+	file, err := os.OpenFile(filepath.Join(w.dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return eris.Wrapf(err, "failed to create recording '%s'", name)
+	}
+
+	w.file = file
+	w.gzWriter = gzip.NewWriter(file)
+	w.bufWriter = bufio.NewWriter(w.gzWriter)
+	w.opened = start
+	w.flushed = time.Now()
+
+	return nil
+}
+
+func (w *Writer) flush() error {
+	err := w.bufWriter.Flush()
+	if err == nil {
+		err = w.gzWriter.Flush()
+	}
+	if err != nil {
+		return eris.Wrapf(err, "failed to flush recording '%s'", w.file.Name())
+	}
+
+	w.flushed = time.Now()
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	flushErr := w.bufWriter.Flush()
+	gzErr := w.gzWriter.Close()
+	fileErr := w.file.Close()
+
+	err := eris.Join(flushErr, gzErr, fileErr)
+	if err != nil {
+		err = eris.Wrapf(err, "failed to close recording '%s'", w.file.Name())
+	}
+
+	w.file, w.gzWriter, w.bufWriter = nil, nil, nil
+	return err
+}
+
+// Returns all recordings matching the given glob pattern (e.g.
+// `/data/binance-*`), sorted by their start time.
+func Files(pattern string) ([]string, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, eris.Wrapf(err, "invalid pattern '%s'", pattern)
+	}
+
+	paths = slices.DeleteFunc(paths, func(path string) bool {
+		return !strings.HasSuffix(path, fileExt)
+	})
+
+	// File names end with their start time; prefixes are equal for a stream.
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// Iterates over all records in the given files. Iteration stops after the
+// first error. A file which ends unexpectedly (e.g. after a crash) ends
+// without error after its last complete record.
+func Records(paths []string) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		for _, path := range paths {
+			more, err := readFile(path, yield)
+			if err != nil {
+				yield(Record{}, eris.Wrapf(err, "failed to read recording '%s'", path))
+				return
+			}
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+func readFile(path string, yield func(Record, error) bool) (more bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() { err = eris.Join(err, file.Close()) }()
+
+	gzReader, err := gzip.NewReader(file)
+	if errors.Is(err, io.EOF) {
+		// Empty file.
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	reader := bufio.NewReader(gzReader)
+
+	for {
+		kind, err := reader.ReadByte()
+		if err != nil {
+			return truncated(err)
+		}
+
+		timestamp, err := binary.ReadVarint(reader)
+		if err != nil {
+			return truncated(err)
+		}
+		length, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return truncated(err)
+		}
+		if length > maxRecordSize {
+			return truncated(eris.Errorf("record of %d bytes exceeds maximum size", length))
+		}
+
+		data := make([]byte, length)
+		_, err = io.ReadFull(reader, data)
+		if err != nil {
+			return truncated(err)
+		}
+
+		if !yield(Record{Kind(kind), time.UnixMilli(timestamp), data}, nil) {
+			return false, nil
+		}
+	}
+}
+
+// Handles an error within a record: A file cut off mid-record is treated
+// like a regular end of file.
+func truncated(err error) (bool, error) {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,87 @@
+package recording
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Ensures that records are read back in order across rotated files and that
+// a truncated file does not prevent reading earlier records.
+func TestWriteAndRead(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writer, err := NewWriter(dir, "test", time.Minute)
+	require.NoError(t, err)
+
+	start := time.UnixMilli(1_700_000_000_000)
+	expected := []Record{
+		{1, start, []byte("first")},
+		{2, start.Add(30 * time.Second), []byte{}},
+		{1, start.Add(90 * time.Second), []byte("third")}, // Rotates.
+		{3, start.Add(91 * time.Second), []byte("fourth")},
+	}
+	for _, rec := range expected {
+		require.NoError(t, writer.Write(rec))
+	}
+	require.NoError(t, writer.Close())
+
+	files, err := Files(filepath.Join(dir, "test-*"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	actual := []Record{}
+	for rec, err := range Records(files) {
+		require.NoError(t, err)
+		actual = append(actual, rec)
+	}
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].Kind, actual[i].Kind)
+		require.True(t, expected[i].Time.Equal(actual[i].Time))
+		require.Equal(t, expected[i].Data, actual[i].Data)
+	}
+
+	// Cut off the second file in the middle of a record.
+	data, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(files[1], data[:len(data)-20], 0o644))
+
+	count := 0
+	for _, err := range Records(files) {
+		require.NoError(t, err)
+		count++
+	}
+	require.Less(t, count, len(expected))
+	require.GreaterOrEqual(t, count, 2)
+}
+
+// Ensures that a corrupt length is reported instead of allocating it.
+func TestReadCorruptLength(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "corrupt"+fileExt)
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	gzWriter := gzip.NewWriter(file)
+	record := binary.AppendVarint([]byte{1}, 1_700_000_000_000)
+	record = binary.AppendUvarint(record, 1<<62)
+	_, err = gzWriter.Write(record)
+	require.NoError(t, err)
+	require.NoError(t, gzWriter.Close())
+	require.NoError(t, file.Close())
+
+	var errs []error
+	for _, err := range Records([]string{path}) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	require.ErrorContains(t, errs[0], "exceeds maximum size")
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Computes exponentially growing delays with jitter, e.g. between attempts to
+// reconnect.
+type Backoff struct {
+	Min time.Duration
+	Max time.Duration
+
+	attempts int
+}
+
+// Returns the delay before the next attempt. The n-th delay is chosen
+// randomly between half and all of `Min * 2^n`, but it never exceeds `Max`.
+func (b *Backoff) Next() time.Duration {
+	delay := b.Min << min(b.attempts, 32)
+	if delay <= 0 || delay > b.Max {
+		delay = b.Max
+	}
+	b.attempts++
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// Restarts with the minimal delay.
+func (b *Backoff) Reset() {
+	b.attempts = 0
+}
+
+// Waits for the given duration. Returns false if the context is cancelled
+// before.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
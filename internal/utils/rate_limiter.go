@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A token bucket: Up to `burst` requests are allowed at once; afterwards,
+// `rate` requests per second. Safe for concurrent use.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Blocks until a request is allowed. Returns false if the context is
+// cancelled before.
+func (l *RateLimiter) Wait(ctx context.Context) bool {
+	wait := l.reserve(time.Now())
+	if wait <= 0 {
+		return true
+	}
+
+	if !Sleep(ctx, wait) {
+		// Return the token.
+		l.lock.Lock()
+		l.tokens++
+		l.lock.Unlock()
+		return false
+	}
+	return true
+}
+
+// Takes a token at the given time, even if it is not available yet; later
+// callers then wait for the following tokens. Returns how long to wait until
+// the token is available.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	l.tokens--
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
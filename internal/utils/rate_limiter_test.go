@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewRateLimiter(50, 5)
+	start := limiter.last
+
+	// The burst is available immediately.
+	for range 5 {
+		require.LessOrEqual(t, limiter.reserve(start), time.Duration(0))
+	}
+
+	// Afterwards, one request every 20ms.
+	for i := range 5 {
+		require.InDelta(t, float64(time.Duration(i+1)*20*time.Millisecond), float64(limiter.reserve(start)), float64(time.Microsecond))
+	}
+
+	// Tokens are refilled over time.
+	require.InDelta(t, float64(80*time.Millisecond), float64(limiter.reserve(start.Add(40*time.Millisecond))), float64(time.Microsecond))
+
+	// Tokens do not accumulate beyond the burst.
+	limiter = NewRateLimiter(50, 5)
+	later := limiter.last.Add(time.Hour)
+	for range 5 {
+		require.LessOrEqual(t, limiter.reserve(later), time.Duration(0))
+	}
+	require.Positive(t, limiter.reserve(later))
+
+	// Waiting obeys the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter = NewRateLimiter(1, 1)
+	require.True(t, limiter.Wait(ctx))
+	require.False(t, limiter.Wait(ctx))
+}
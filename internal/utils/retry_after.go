@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Returns how long a server asks to wait via the `Retry-After` header of the
+// given response, or 0 if it does not.
+func RetryAfter(res *http.Response) time.Duration {
+	value := res.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return max(0, time.Duration(seconds)*time.Second)
+	}
+
+	date, err := http.ParseTime(value)
+	if err == nil {
+		return max(0, time.Until(date))
+	}
+
+	return 0
+}